@@ -0,0 +1,205 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/google/shlex"
+)
+
+// CommandChainItem is a command line in a chain, run after the previous one according to the operator
+type CommandChainItem struct {
+	Operator    string // ";" or "&&", empty for the first item
+	CommandLine string
+}
+
+// The maximum depth of aliases referring to other aliases
+const MaxAliasDepth = 10
+
+// Returns true if the given alias name is valid
+// No side effect
+var IsValidAliasName = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`).MatchString
+
+// GetAliasFilePath returns the path of the alias file in the administrator directory.
+// No side effect
+func GetAliasFilePath() string {
+	return filepath.Join(AdminDir, ".cmapi-cli-aliases.json")
+}
+
+// GetAliases returns all aliases. An empty map is returned if there is no alias file.
+// No side effect
+func GetAliases() map[string]string {
+	aliases := ReadJson(GetAliasFilePath())
+	if aliases == nil {
+		return map[string]string{}
+	}
+	return aliases
+}
+
+// SplitCommandChain splits the command line by ';' and '&&' outside of quotes.
+// No side effect
+func SplitCommandChain(commandLine string) ([]CommandChainItem, error) {
+	rtn := []CommandChainItem{}
+
+	var current strings.Builder
+	operator := ""
+	var quote rune
+	escaped := false
+
+	runes := []rune(commandLine)
+	for i := 0; i < len(runes); i++ {
+		c := runes[i]
+
+		if escaped {
+			escaped = false
+		} else if c == '\\' && quote != '\'' {
+			escaped = true
+		} else if quote != 0 {
+			if c == quote {
+				quote = 0
+			}
+		} else if c == '\'' || c == '"' {
+			quote = c
+		} else if c == ';' || (c == '&' && i+1 < len(runes) && runes[i+1] == '&') {
+			rtn = append(rtn, CommandChainItem{operator, strings.TrimSpace(current.String())})
+			current.Reset()
+			if c == ';' {
+				operator = ";"
+			} else {
+				operator = "&&"
+				i++
+			}
+			continue
+		}
+
+		current.WriteRune(c)
+	}
+
+	if quote != 0 || escaped {
+		return nil, errors.New("unterminated quote or escape")
+	}
+
+	rtn = append(rtn, CommandChainItem{operator, strings.TrimSpace(current.String())})
+	return rtn, nil
+}
+
+// QuoteArgs joins the arguments into a command line, quoting the arguments if needed.
+// No side effect
+func QuoteArgs(args []string) string {
+	rtn := []string{}
+	for _, arg := range args {
+		if arg == "" || strings.ContainsAny(arg, " \t\n'\"\\;&#") {
+			arg = `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`).Replace(arg) + `"`
+		}
+		rtn = append(rtn, arg)
+	}
+	return strings.Join(rtn, " ")
+}
+
+// RunCommandLine runs a command line which may contain aliases and commands chained by ';' and '&&'.
+// A command after '&&' runs only if the previous command succeeded.
+// Returns true if the last command that ran succeeded
+func RunCommandLine(commandLine string) bool {
+	return runCommandLine(commandLine, 0)
+}
+
+func runCommandLine(commandLine string, depth int) bool {
+	chain, err := SplitCommandChain(commandLine)
+	if err != nil {
+		return ReportError(WrapError(err, 300))
+	}
+
+	success := true
+	for _, item := range chain {
+		if item.Operator == "&&" && !success {
+			continue
+		}
+
+		input, err := shlex.Split(item.CommandLine)
+		if err != nil {
+			success = ReportError(WrapError(err, 300))
+			continue
+		}
+
+		if len(input) == 0 {
+			continue
+		}
+
+		if expansion, ok := GetAliases()[input[0]]; ok {
+			if depth >= MaxAliasDepth {
+				success = ReportError(NewError(208, input[0]))
+				continue
+			}
+			success = runCommandLine(expansion+" "+QuoteArgs(input[1:]), depth+1)
+		} else {
+			success = HandleCommand(input[0], input[1:])
+		}
+	}
+
+	return success
+}
+
+// AliasCommand lists, shows, sets or removes aliases.
+// The arguments are in the form of "NAME = COMMAND...", "NAME=COMMAND..." or "NAME COMMAND...".
+// An empty command removes the alias.
+func AliasCommand(args []string) error {
+	aliases := GetAliases()
+
+	if len(args) == 0 {
+		ReportData("aliases", aliases)
+		fmt.Fprintln(Console, HeaderText(T("aliases-listing")))
+		for _, name := range GetSortedKeys(aliases) {
+			fmt.Fprintln(Console, InfoText(name+" = ")+aliases[name])
+		}
+		return nil
+	}
+
+	name := args[0]
+	value := args[1:]
+	if before, after, found := strings.Cut(name, "="); found {
+		name = before
+		value = append([]string{after}, value...)
+	} else if len(value) > 0 && value[0] == "=" {
+		value = value[1:]
+	} else if len(value) == 0 {
+		expansion, ok := aliases[name]
+		if !ok {
+			return NewError(209, name)
+		}
+		ReportData("aliases", map[string]string{name: expansion})
+		fmt.Fprintln(Console, InfoText(name+" = ")+expansion)
+		return nil
+	}
+
+	if !IsValidAliasName(name) {
+		return NewError(206, name)
+	}
+	if FindCommand(name) != nil {
+		return NewError(207, name)
+	}
+
+	expansion := strings.TrimSpace(strings.Join(value, " "))
+	if expansion == "" {
+		if _, ok := aliases[name]; !ok {
+			return NewError(209, name)
+		}
+		delete(aliases, name)
+	} else {
+		if _, err := SplitCommandChain(expansion); err != nil {
+			return WrapError(err, 300)
+		}
+		aliases[name] = expansion
+	}
+
+	if !WriteJson(GetAliasFilePath(), aliases) {
+		return NewError(154)
+	}
+
+	if expansion == "" {
+		return Success("alias-removed", name)
+	}
+	return Success("alias-set", name, expansion)
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/google/shlex"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitCommandChain(t *testing.T) {
+	chain, err := SplitCommandChain(`all --slot 2 ; backup&&pull;`)
+	assert.Nil(t, err)
+	assert.Equal(t, []CommandChainItem{
+		{"", "all --slot 2"},
+		{";", "backup"},
+		{"&&", "pull"},
+		{";", ""},
+	}, chain)
+
+	chain, err = SplitCommandChain(`alias ship = "all --slot 2 ; backup" && echo 'a&&b' \; c`)
+	assert.Nil(t, err)
+	assert.Equal(t, []CommandChainItem{
+		{"", `alias ship = "all --slot 2 ; backup"`},
+		{"&&", `echo 'a&&b' \; c`},
+	}, chain)
+
+	_, err = SplitCommandChain(`alias ship = "all`)
+	assert.NotNil(t, err)
+}
+
+func TestQuoteArgs(t *testing.T) {
+	args := []string{"--slot", "2", "a b", `quote"d`, `back\slash`, "", "x;y"}
+
+	parsed, err := shlex.Split(QuoteArgs(args))
+	assert.Nil(t, err)
+	assert.Equal(t, args, parsed)
+}
+
+func TestAliasCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	AdminDir = wd
+	defer os.Remove(GetAliasFilePath())
+
+	assert.Nil(t, AliasCommand([]string{"ship", "=", "all --slot 2 ; backup"}))
+	assert.Nil(t, AliasCommand([]string{"up=pull"}))
+	assert.Nil(t, AliasCommand([]string{"sync", "up", "&&", "backup"}))
+	assert.Equal(t, map[string]string{
+		"ship": "all --slot 2 ; backup",
+		"up":   "pull",
+		"sync": "up && backup",
+	}, GetAliases())
+
+	assert.Nil(t, AliasCommand([]string{"ship"}))
+	assert.Nil(t, AliasCommand(nil))
+	assert.Equal(t, 207, GetErrorCode(AliasCommand([]string{"backup", "=", "pull"})))
+	assert.Equal(t, 206, GetErrorCode(AliasCommand([]string{"a.b", "=", "pull"})))
+	assert.Equal(t, 209, GetErrorCode(AliasCommand([]string{"missing"})))
+
+	assert.Nil(t, AliasCommand([]string{"ship", "="}))
+	assert.NotContains(t, GetAliases(), "ship")
+}
+
+func TestRunCommandLine(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	AdminDir = wd
+	WorkingDir = wd
+	defer os.Remove(GetAliasFilePath())
+
+	WriteJson(GetAliasFilePath(), map[string]string{
+		"up":   "pull",
+		"sync": "up && backup",
+		"loop": "loop",
+	})
+
+	MockCommandsQueue = []CommandSpec{
+		// sync, pull failed so backup is skipped
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "", 1},
+		// sync ; pull
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 0},
+		{"git push -u origin master", "", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "", 0},
+	}
+
+	assert.False(t, RunCommandLine("sync"))
+	assert.True(t, RunCommandLine("sync ; pull"))
+	assert.False(t, RunCommandLine("loop"))
+	assert.False(t, RunCommandLine("unknown && pull"))
+	assert.False(t, RunCommandLine(`pull "`))
+	assert.Empty(t, MockCommandsQueue)
+}
@@ -6,6 +6,14 @@ package main
 import (
 	"os"
 	"os/exec"
+	"syscall"
+	"unsafe"
+)
+
+const (
+	// sys/ttycom.h, flush the queue given by the flag
+	tiocflush = 0x80047410
+	fread     = 0x1
 )
 
 var (
@@ -21,7 +29,7 @@ func Beep(freq float64, duration int) error {
 	osa, err := exec.LookPath("osascript")
 	if err != nil {
 		// Output the only beep we can
-		_, err = os.Stdout.Write([]byte{7})
+		_, err = Console.Write([]byte{7})
 		return err
 	}
 
@@ -32,3 +40,17 @@ func Beep(freq float64, duration int) error {
 func FixConsoleColor() {
 	// Empty
 }
+
+// FlushInput discards the characters typed while a command was running
+func FlushInput() {
+	flag := int32(fread)
+	syscall.Syscall(syscall.SYS_IOCTL, os.Stdin.Fd(), tiocflush, uintptr(unsafe.Pointer(&flag)))
+}
+
+// SerialDevicePatterns are the glob patterns of the serial devices of the V5 Brain and controller
+var SerialDevicePatterns = []string{"/dev/cu.usbmodem*"}
+
+// IsDeviceAccessible returns true if the user can read and write the device
+func IsDeviceAccessible(path string) bool {
+	return syscall.Access(path, 0x4|0x2) == nil // R_OK | W_OK
+}
@@ -6,6 +6,7 @@ package main
 import (
 	"errors"
 	"os"
+	"runtime"
 	"syscall"
 	"time"
 	"unsafe"
@@ -23,6 +24,14 @@ const (
 	// linux/input-event-codes.h
 	evSnd   = 0x12 // Event type
 	sndTone = 0x02 // Sound
+
+	// asm-generic/ioctls.h, flush the input queue with TCIFLUSH
+	tcflsh   = 0x540B
+	tciflush = 0
+
+	// sys/ttycom.h on BSD, flush the queue given by the flag
+	tiocflush = 0x80047410
+	fread     = 0x1
 )
 
 var (
@@ -89,7 +98,7 @@ func Beep(freq float64, duration int) error {
 			e = errors.New("beeep: " + e.Error() + "; " + err.Error())
 
 			// Output the only beep we can
-			_, err = os.Stdout.Write([]byte{7})
+			_, err = Console.Write([]byte{7})
 			if err != nil {
 				return errors.New(e.Error() + "; " + err.Error())
 			}
@@ -142,3 +151,21 @@ func Beep(freq float64, duration int) error {
 func FixConsoleColor() {
 	// Empty
 }
+
+// FlushInput discards the characters typed while a command was running
+func FlushInput() {
+	if runtime.GOOS == "linux" {
+		ioctl(os.Stdin.Fd(), tcflsh, tciflush)
+	} else {
+		flag := int32(fread)
+		ioctl(os.Stdin.Fd(), tiocflush, uintptr(unsafe.Pointer(&flag)))
+	}
+}
+
+// SerialDevicePatterns are the glob patterns of the serial devices of the V5 Brain and controller
+var SerialDevicePatterns = []string{"/dev/ttyACM*"}
+
+// IsDeviceAccessible returns true if the user can read and write the device
+func IsDeviceAccessible(path string) bool {
+	return syscall.Access(path, 0x4|0x2) == nil // R_OK | W_OK
+}
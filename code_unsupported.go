@@ -19,3 +19,15 @@ func Beep(freq float64, duration int) error {
 func FixConsoleColor() {
 	// Empty
 }
+
+func FlushInput() {
+	// Empty
+}
+
+// SerialDevicePatterns are the glob patterns of the serial devices of the V5 Brain and controller,
+// nil if the devices cannot be listed on this platform
+var SerialDevicePatterns []string
+
+func IsDeviceAccessible(path string) bool {
+	return true
+}
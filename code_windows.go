@@ -54,3 +54,18 @@ func FixConsoleColor() {
 
 	syscall.MustLoadDLL("kernel32").MustFindProc("SetConsoleMode").Call(uintptr(stdout), uintptr(originalMode))
 }
+
+// FlushInput discards the characters typed while a command was running
+func FlushInput() {
+	stdin := syscall.Handle(os.Stdin.Fd())
+
+	syscall.MustLoadDLL("kernel32").MustFindProc("FlushConsoleInputBuffer").Call(uintptr(stdin))
+}
+
+// SerialDevicePatterns are the glob patterns of the serial devices of the V5 Brain and controller,
+// nil if the devices cannot be listed on this platform
+var SerialDevicePatterns []string
+
+func IsDeviceAccessible(path string) bool {
+	return true
+}
@@ -0,0 +1,667 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Option is an option which can be accepted by commands
+type Option struct {
+	Short string
+	Long  string
+	Value string // placeholder of the value, empty for boolean options
+	Help  string
+}
+
+// CommandOptions holds the values of the options parsed from the command line
+type CommandOptions struct {
+	Color        string
+	WorkspaceDir string
+	Fix          bool
+	Force        bool
+	Kernel       string
+	Local        bool
+	NoPull       bool
+	Output       string
+	Slot         int
+	Template     string
+	Verbose      bool
+	Yes          bool
+}
+
+// Command is a command which can be run in the prompt
+type Command struct {
+	Name    string
+	Aliases []string
+	Group   string
+	Args    string   // argument spec shown in the usage
+	MinArgs int      // minimum number of arguments
+	MaxArgs int      // maximum number of arguments, -1 for unlimited
+	Options []string // long names of the accepted options
+	Actions []string // sub-commands, used for tab completion
+	Help    string
+	Run     func(opts CommandOptions, args []string) error
+}
+
+const (
+	GroupProject    = "group-project"
+	GroupRepository = "group-repository"
+)
+
+var Options = []Option{
+	{"c", "color", "<WHEN>", "When to color the output. [default: auto,\nvalues: auto, always, never]"},
+	{"d", "directory", "<PATH>", "The workspace directory. The parent directory\nof where all repositories located at.\n[default: DEFAULT SETTING]"},
+	{"fx", "fix", "", "Apply the safe fixes, such as adding the exports\nto the shell startup file."},
+	{"f", "force", "", "Force the action to run."},
+	{"k", "kernel", "<VERSION>", "The kernel version to use. [default: latest]"},
+	{"l", "local", "", "Do not create a repository on the server."},
+	{"np", "no-pull", "", "Do not pull template changes/kernel online."},
+	{"o", "output", "<FORMAT>", "Print the result as one JSON object per command\nwith 'json', the other messages go to stderr.\n[default: text, values: text, json]"},
+	{"s", "slot", "<SLOT>", "Upload the binary to a specified program slot\nin the brain. [default: 1, range: 1-8]"},
+	{"t", "template", "<NAME>", "The template to create the project from, one of\nthe templates in the secret. [default: the\ntemplate repository]"},
+	{"v", "verbose", "", "Show the causes of errors."},
+	{"y", "yes", "", "Use the similar label of a repository on the\nserver without asking."},
+}
+
+// GlobalOptions are the long names of the options accepted by all commands
+var GlobalOptions = []string{"color", "output", "verbose"}
+
+// Commands is the registry of all commands, in the order shown in the usage
+var Commands []*Command
+
+func init() {
+	Commands = []*Command{
+		{
+			Name:    "all",
+			Group:   GroupProject,
+			Options: []string{"slot"},
+			Help: "Remove all object files in the project's ./bin directory and compile\n" +
+				"all source files again. Attempt to connect the V5 Brain and upload the\n" +
+				"binary files.",
+			Run: func(opts CommandOptions, args []string) error {
+				return CompileCommand(WorkingDir, true, opts.Slot)
+			},
+		},
+		{
+			Name:  "b",
+			Group: GroupProject,
+			Help:  "Compile source files normally in the current PROS project without\nuploading.",
+			Run: func(opts CommandOptions, args []string) error {
+				return BuildCommand(WorkingDir)
+			},
+		},
+		{
+			Name:  "backup",
+			Group: GroupProject,
+			Help:  "Commit and push all changes in the repository to the remote server.",
+			Run: func(opts CommandOptions, args []string) error {
+				return BackupCommand(WorkingDir)
+			},
+		},
+		{
+			Name:    "init",
+			Group:   GroupProject,
+			Options: []string{"kernel", "no-pull", "force"},
+			Help: "Initialize the Git repository and create the PROS project. Apply the\n" +
+				"kernel to the project without overwriting any existing files.",
+			Run: func(opts CommandOptions, args []string) error {
+				return InitProjectCommand(WorkingDir, opts.Kernel, opts.Force, opts.NoPull)
+			},
+		},
+		{
+			Name:    "kernel",
+			Group:   GroupProject,
+			Args:    "[check | list | pin <VERSION> | upgrade [VERSION]]",
+			MaxArgs: 2,
+			Options: []string{"no-pull"},
+			Actions: []string{"check", "list", "pin", "upgrade"},
+			Help: "Check if the installed kernel matches the version pinned in the project\n" +
+				"config, list the available kernels, pin the kernel version, or apply\n" +
+				"the pinned, given or latest kernel. The project is rolled back if it\n" +
+				"fails to build with the new kernel.",
+			Run: func(opts CommandOptions, args []string) error {
+				return KernelCommand(WorkingDir, GetArg(args, 0), GetArg(args, 1), opts.NoPull)
+			},
+		},
+		{
+			Name:    "link",
+			Group:   GroupProject,
+			Args:    "[PROJECT_SLUG]",
+			MaxArgs: 1,
+			Help: "Link the current directory to a remote repository on Bitbucket. The\n" +
+				"project slug is the same as the project root directory name by default.",
+			Run: func(opts CommandOptions, args []string) error {
+				repoSlug := filepath.Base(WorkingDir)
+				if len(args) > 0 {
+					repoSlug = args[0]
+				}
+				return LinkLocalRepoToServerCommand(WorkingDir, repoSlug)
+			},
+		},
+		{
+			Name:    "normal",
+			Group:   GroupProject,
+			Options: []string{"slot"},
+			Help: "Compile source files normally in the current PROS project. Attempt to\n" +
+				"connect the V5 Brain and upload the binary files.",
+			Run: func(opts CommandOptions, args []string) error {
+				return CompileCommand(WorkingDir, false, opts.Slot)
+			},
+		},
+		{
+			Name:  "pull",
+			Group: GroupProject,
+			Help:  "Pull changes from the remote server to the local repository.",
+			Run: func(opts CommandOptions, args []string) error {
+				return PullCommand(WorkingDir)
+			},
+		},
+		{
+			Name:  "status",
+			Group: GroupProject,
+			Help:  "Show the branch, the uncommitted changes and the kernel of the project.",
+			Run: func(opts CommandOptions, args []string) error {
+				return StatusCommand(WorkingDir)
+			},
+		},
+		{
+			Name:    "template",
+			Group:   GroupProject,
+			Args:    "[list | add <NAME>[@VERSION] | remove <NAME> | update [NAME[@VERSION]]]",
+			MaxArgs: 2,
+			Options: []string{"no-pull"},
+			Actions: []string{"add", "list", "remove", "update"},
+			Help: "List, add, remove or update the templates (e.g. okapilib, LemLib) the\n" +
+				"project depends on. They are recorded in the project config and\n" +
+				"applied again when the project is cloned or initialized.",
+			Run: func(opts CommandOptions, args []string) error {
+				return TemplateCommand(WorkingDir, GetArg(args, 0), GetArg(args, 1), opts.NoPull)
+			},
+		},
+		{
+			Name:    "template-sync",
+			Group:   GroupProject,
+			Options: []string{"no-pull"},
+			Help: "Merge the changes made to the template repository since the project was\n" +
+				"created or last synced into the project, on a new branch. Conflicts\n" +
+				"are left in the files to be resolved and committed.",
+			Run: func(opts CommandOptions, args []string) error {
+				return TemplateSyncCommand(WorkingDir, opts.NoPull)
+			},
+		},
+		{
+			Name:    "variants",
+			Group:   GroupProject,
+			Args:    "[list | build | upload] [NAME, ...]",
+			MaxArgs: -1,
+			Actions: []string{"build", "list", "upload"},
+			Help: "List the build variants declared in the project config, or build each\n" +
+				"variant with its compile-time defines into 'bin/variants/<NAME>'. The\n" +
+				"'upload' action also uploads each variant to its own slot. All\n" +
+				"variants are selected if no name is given.",
+			Run: func(opts CommandOptions, args []string) error {
+				names := []string{}
+				if len(args) > 1 {
+					names = args[1:]
+				}
+				return VariantsCommand(WorkingDir, GetArg(args, 0), names)
+			},
+		},
+		{
+			Name:    "clone",
+			Group:   GroupRepository,
+			Args:    "<LABEL>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory", "kernel", "no-pull", "yes"},
+			Help: "1. Clone a repository from the server to the local machine.\n" +
+				"2. Initialize the PROS project and apply the recorded templates.",
+			Run: func(opts CommandOptions, args []string) error {
+				label := NormalizeLabel(args[0])
+				if !IsValidLabel(label) {
+					return NewError(200)
+				}
+				label, err := ResolveRemoteLabel(label, opts.Yes)
+				if err != nil {
+					return err
+				}
+				return CloneRepositoryCommand(label, opts.WorkspaceDir, opts.Kernel, opts.NoPull)
+			},
+		},
+		{
+			Name:    "create",
+			Group:   GroupRepository,
+			Args:    "<LABEL>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory", "kernel", "no-pull", "local", "template"},
+			Help: "1. Create a repository on the local machine. The label is changed to\n" +
+				"capital letters, with hyphens instead of spaces and underscores.\n" +
+				"2. Fork all contents from the template, replacing placeholders like\n" +
+				"{{label}} in the files and their names.\n" +
+				"3. Initialize the PROS project.\n" +
+				"4. Upload the repository to the server.",
+			Run: func(opts CommandOptions, args []string) error {
+				label := NormalizeLabel(args[0])
+				if !IsValidLabel(label) {
+					return NewError(200)
+				}
+				return CreateRepositoryCommand(label, opts.WorkspaceDir, opts.Template, opts.Kernel, opts.NoPull, opts.Local)
+			},
+		},
+		{
+			Name:    "rename",
+			Group:   GroupRepository,
+			Args:    "<OLD> <NEW>",
+			MinArgs: 2,
+			MaxArgs: 2,
+			Options: []string{"directory"},
+			Help: "Move the local project to the directory of the new label, rename\n" +
+				"the repository on the server, link the project to the renamed\n" +
+				"repository and commit the new project name.",
+			Run: func(opts CommandOptions, args []string) error {
+				return RenameCommand(NormalizeLabel(args[0]), NormalizeLabel(args[1]), opts.WorkspaceDir)
+			},
+		},
+		{
+			Name:    "archive",
+			Group:   GroupRepository,
+			Args:    "<LABEL>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory"},
+			Help: "Move the repository on the server to the archive project and remove\n" +
+				"the local project. Nothing is changed if the local project has\n" +
+				"changes, stashes or commits which are not pushed to the server.",
+			Run: func(opts CommandOptions, args []string) error {
+				return ArchiveCommand(NormalizeLabel(args[0]), opts.WorkspaceDir)
+			},
+		},
+		{
+			Name:    "projects",
+			Group:   GroupRepository,
+			Options: []string{"directory"},
+			Help: "List the projects in the workspace directory with their branch,\n" +
+				"uncommitted changes, commits ahead of and behind the server, last\n" +
+				"commit, kernel and last successful build.",
+			Run: func(opts CommandOptions, args []string) error {
+				return ProjectsCommand(opts.WorkspaceDir)
+			},
+		},
+		{
+			Name:    "workspace",
+			Group:   GroupRepository,
+			Args:    "<pull | backup | status>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory"},
+			Actions: []string{"backup", "pull", "status"},
+			Help: "Pull, back up or show the status of every repository in the workspace\n" +
+				"directory, a few at the same time. A failed project does not stop the\n" +
+				"others. The results are shown in a table at the end.",
+			Run: func(opts CommandOptions, args []string) error {
+				return WorkspaceCommand(opts.WorkspaceDir, args[0])
+			},
+		},
+		{
+			Name:    "cd",
+			Group:   GroupRepository,
+			Args:    "<LABEL | PATH>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory"},
+			Help: "Make the directory, or the project with the label in the workspace\n" +
+				"directory, the active project. Relative paths are resolved from the\n" +
+				"active project.",
+			Run: func(opts CommandOptions, args []string) error {
+				return CdCommand(args[0], opts.WorkspaceDir)
+			},
+		},
+		{
+			Name:    "open",
+			Group:   GroupRepository,
+			Args:    "<LABEL>",
+			MinArgs: 1,
+			MaxArgs: 1,
+			Options: []string{"directory", "kernel", "no-pull", "yes"},
+			Help: "Make the project with the label in the workspace directory the active\n" +
+				"project. The project is cloned from the server first if it does not\n" +
+				"exist.",
+			Run: func(opts CommandOptions, args []string) error {
+				return OpenCommand(NormalizeLabel(args[0]), opts.WorkspaceDir, opts.Kernel, opts.NoPull, opts.Yes)
+			},
+		},
+		{
+			Name:  "recent",
+			Group: GroupRepository,
+			Help:  "List the projects which were recently the active project.",
+			Run: func(opts CommandOptions, args []string) error {
+				return RecentCommand()
+			},
+		},
+		{
+			Name:    "alias",
+			Group:   GroupRepository,
+			Args:    `[NAME [= "COMMAND"]]`,
+			MaxArgs: -1,
+			Help: "List all aliases, show an alias, or save a command line as an alias.\n" +
+				"Quote the command line if it contains ';' or '&&'. An empty command\n" +
+				"removes the alias. Extra arguments given to an alias are appended to\n" +
+				"its command line.",
+			Run: func(opts CommandOptions, args []string) error {
+				return AliasCommand(args)
+			},
+		},
+		{
+			Name:    "help",
+			Group:   GroupRepository,
+			Args:    "[COMMAND]",
+			MaxArgs: 1,
+			Help:    "Display this help message, or the help message of a command.",
+			Run: func(opts CommandOptions, args []string) error {
+				return HelpCommand(GetArg(args, 0))
+			},
+		},
+		{
+			Name:    "history",
+			Group:   GroupRepository,
+			Args:    "[COUNT]",
+			MaxArgs: 1,
+			Help: "Show the most recent build and upload runs and the average durations\n" +
+				"of each project. [default: 10]",
+			Run: func(opts CommandOptions, args []string) error {
+				count := 10
+				if len(args) > 0 {
+					n, err := strconv.Atoi(args[0])
+					if err != nil || n <= 0 {
+						return NewError(202, args[0])
+					}
+					count = n
+				}
+				return HistoryCommand(count)
+			},
+		},
+		{
+			Name:    "logs",
+			Group:   GroupRepository,
+			Args:    "[N]",
+			MaxArgs: 1,
+			Help: "Show the Git, PROS and other commands run in the N-th last session,\n" +
+				"with their exit codes and output. [default: 1, the current session]",
+			Run: func(opts CommandOptions, args []string) error {
+				n := 1
+				if len(args) > 0 {
+					i, err := strconv.Atoi(args[0])
+					if err != nil || i <= 0 {
+						return NewError(202, args[0])
+					}
+					n = i
+				}
+				return LogsCommand(n)
+			},
+		},
+		{
+			Name:  "report",
+			Group: GroupRepository,
+			Help: "Bundle the logs, the settings without the password and the versions\n" +
+				"of the tools into a zip file to attach to an issue.",
+			Run: func(opts CommandOptions, args []string) error {
+				return ReportCommand()
+			},
+		},
+		{
+			Name:    "doctor",
+			Group:   GroupRepository,
+			Options: []string{"fix"},
+			Help: "Check the tools, PROS_TOOLCHAIN, the administrator directory, the\n" +
+				"secret file, the credentials and the serial devices, and show how to\n" +
+				"fix the problems found.",
+			Run: func(opts CommandOptions, args []string) error {
+				return DoctorCommand(opts.Fix)
+			},
+		},
+		{
+			Name:    "update",
+			Group:   GroupRepository,
+			Args:    "[check]",
+			MaxArgs: 1,
+			Actions: []string{"check"},
+			Help: "Check the latest release of CMAPI-CLI on GitHub, and download and\n" +
+				"install it if it is newer. The 'check' action only shows if there is a\n" +
+				"newer version.",
+			Run: func(opts CommandOptions, args []string) error {
+				return UpdateCommand(GetArg(args, 0))
+			},
+		},
+		{
+			Name:    "secret",
+			Group:   GroupRepository,
+			Args:    "[<KEY> [<VALUE>]]",
+			MaxArgs: 2,
+			Help:    "List all secret keys and values, show the value of a secret key or set\na secret key and value.",
+			Run: func(opts CommandOptions, args []string) error {
+				if len(args) == 0 {
+					return ListSecretsCommand()
+				} else if len(args) == 1 {
+					return ShowSecretCommand(args[0])
+				} else {
+					return SetSecretCommand(args[0], args[1])
+				}
+			},
+		},
+	}
+}
+
+// GetArg returns the argument at the given index, or an empty string if there is no such argument.
+// No side effect
+func GetArg(args []string, i int) string {
+	if i < len(args) {
+		return args[i]
+	}
+	return ""
+}
+
+// FindCommand returns the command with the given name or alias, or nil if not found.
+// No side effect
+func FindCommand(name string) *Command {
+	for _, cmd := range Commands {
+		if cmd.Name == name || Contains(cmd.Aliases, name) {
+			return cmd
+		}
+	}
+	return nil
+}
+
+// GetCommandNames returns the names and aliases of all commands, sorted.
+// No side effect
+func GetCommandNames() []string {
+	rtn := []string{}
+	for _, cmd := range Commands {
+		rtn = append(rtn, cmd.Name)
+		rtn = append(rtn, cmd.Aliases...)
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+// FindOption returns the option with the given long name, or nil if not found.
+// No side effect
+func FindOption(long string) *Option {
+	for i := range Options {
+		if Options[i].Long == long {
+			return &Options[i]
+		}
+	}
+	return nil
+}
+
+// NewCommandFlagSet returns the flag set of the options accepted by the command
+func NewCommandFlagSet(cmd *Command, opts *CommandOptions) *flag.FlagSet {
+	fs := flag.NewFlagSet(cmd.Name, flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+
+	// Default values, even if the option is not accepted
+	opts.Color = ColorMode
+	opts.WorkspaceDir = Secret["workspace-dir"]
+	opts.Kernel = "latest"
+	opts.Output = OutputFormat
+	opts.Slot = 1
+	opts.Verbose = Verbose
+
+	for _, long := range append(append([]string{}, cmd.Options...), GlobalOptions...) {
+		option := FindOption(long)
+		for _, name := range []string{option.Short, option.Long} {
+			switch long {
+			case "color":
+				fs.StringVar(&opts.Color, name, opts.Color, "")
+			case "directory":
+				fs.StringVar(&opts.WorkspaceDir, name, opts.WorkspaceDir, "")
+			case "fix":
+				fs.BoolVar(&opts.Fix, name, false, "")
+			case "force":
+				fs.BoolVar(&opts.Force, name, false, "")
+			case "kernel":
+				fs.StringVar(&opts.Kernel, name, opts.Kernel, "")
+			case "local":
+				fs.BoolVar(&opts.Local, name, false, "")
+			case "no-pull":
+				fs.BoolVar(&opts.NoPull, name, false, "")
+			case "output":
+				fs.StringVar(&opts.Output, name, opts.Output, "")
+			case "slot":
+				fs.IntVar(&opts.Slot, name, opts.Slot, "")
+			case "template":
+				fs.StringVar(&opts.Template, name, "", "")
+			case "verbose":
+				fs.BoolVar(&opts.Verbose, name, opts.Verbose, "")
+			case "yes":
+				fs.BoolVar(&opts.Yes, name, false, "")
+			}
+		}
+	}
+
+	return fs
+}
+
+// GetCommandSynopsis returns the name, options and arguments of the command in one line.
+// No side effect
+func GetCommandSynopsis(cmd *Command) string {
+	parts := []string{cmd.Name}
+	for _, long := range cmd.Options {
+		option := FindOption(long)
+		if option.Value != "" {
+			parts = append(parts, "[--"+option.Long+" "+option.Value+"]")
+		} else {
+			parts = append(parts, "[--"+option.Long+"]")
+		}
+	}
+	if cmd.Args != "" {
+		parts = append(parts, cmd.Args)
+	}
+	return strings.Join(parts, " ")
+}
+
+// WrapWords wraps the text into lines no longer than the width, and indents every line.
+// No side effect
+func WrapWords(text string, indent string, width int) string {
+	lines := []string{}
+	current := ""
+	for _, word := range strings.Fields(text) {
+		if current != "" && len(indent)+len(current)+1+len(word) > width {
+			lines = append(lines, indent+current)
+			current = word
+		} else if current == "" {
+			current = word
+		} else {
+			current += " " + word
+		}
+	}
+	if current != "" {
+		lines = append(lines, indent+current)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// IndentLines indents every line of the text.
+// No side effect
+func IndentLines(text string, indent string) string {
+	return indent + strings.ReplaceAll(text, "\n", "\n"+indent)
+}
+
+// GetOptionsUsage returns the usage of the given options.
+// No side effect
+func GetOptionsUsage(longs []string) string {
+	rtn := []string{}
+	for _, long := range longs {
+		option := FindOption(long)
+		head := fmt.Sprintf("    %-4s --%s %s", "-"+option.Short+",", option.Long, option.Value)
+		help := strings.Split(GetMessage("option-"+long, option.Help), "\n")
+		rtn = append(rtn, fmt.Sprintf("%-32s%s", head, help[0]))
+		for _, line := range help[1:] {
+			rtn = append(rtn, strings.Repeat(" ", 32)+line)
+		}
+	}
+	return strings.Join(rtn, "\n")
+}
+
+// GetCommandUsage returns the synopsis and the help text of the command.
+// No side effect
+func GetCommandUsage(cmd *Command) string {
+	synopsis := strings.TrimPrefix(WrapWords(GetCommandSynopsis(cmd), "        ", 80), "        ")
+	return "    " + synopsis + "\n" + IndentLines(GetMessage("help-"+cmd.Name, cmd.Help), "        ")
+}
+
+// GetUsage returns the usage of all commands.
+// No side effect
+func GetUsage() string {
+	var sb strings.Builder
+
+	sb.WriteString(T("usage") + "\n")
+
+	for _, group := range []string{GroupProject, GroupRepository} {
+		sb.WriteString("\n" + T(group) + "\n")
+		for _, cmd := range Commands {
+			if cmd.Group == group {
+				sb.WriteString(GetCommandUsage(cmd) + "\n")
+			}
+		}
+	}
+
+	longs := []string{}
+	for _, option := range Options {
+		longs = append(longs, option.Long)
+	}
+	sb.WriteString("\n" + T("usage-options") + "\n" + GetOptionsUsage(longs) + "\n")
+	sb.WriteString("\n" + T("usage-version") + Version)
+
+	return sb.String()
+}
+
+// HelpCommand displays the usage of all commands, or the usage of the given command
+func HelpCommand(name string) error {
+	if name == "" {
+		fmt.Fprintln(Console, InfoText(GetUsage()))
+		return nil
+	}
+
+	cmd := FindCommand(name)
+	if cmd == nil {
+		return NewError(301, name)
+	}
+
+	text := T("usage-title") + "\n" + GetCommandUsage(cmd)
+	if len(cmd.Aliases) != 0 {
+		text += "\n\n" + T("usage-aliases") + strings.Join(cmd.Aliases, ", ")
+	}
+	if len(cmd.Options) != 0 {
+		text += "\n\n" + T("usage-options") + "\n" + GetOptionsUsage(cmd.Options)
+	}
+	fmt.Fprintln(Console, InfoText(text))
+
+	return nil
+}
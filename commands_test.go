@@ -0,0 +1,74 @@
+package main
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindCommand(t *testing.T) {
+	assert.Equal(t, "b", FindCommand("b").Name)
+	assert.Equal(t, "normal", FindCommand("normal").Name)
+	assert.Nil(t, FindCommand("unknown"))
+
+	names := GetCommandNames()
+	assert.Contains(t, names, "b")
+	assert.NotContains(t, names, "build")
+	assert.Contains(t, names, "variants")
+}
+
+func TestHandleCommandOptions(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	WorkingDir = wd
+
+	// options and arguments are checked before anything runs
+	assert.False(t, HandleCommand("backup", []string{"--slot", "3"}))
+	assert.False(t, HandleCommand("normal", []string{"--force"}))
+	assert.False(t, HandleCommand("clone", []string{}))
+	assert.False(t, HandleCommand("pull", []string{"extra"}))
+	assert.False(t, HandleCommand("unknown", []string{}))
+
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "", 0},
+	}
+	assert.True(t, HandleCommand("pull", []string{}))
+	assert.Empty(t, MockCommandsQueue)
+}
+
+func TestNewCommandFlagSet(t *testing.T) {
+	var opts CommandOptions
+	fs := NewCommandFlagSet(FindCommand("clone"), &opts)
+
+	assert.Nil(t, fs.Parse([]string{"-d", "/tmp/ws", "--kernel", "3.8.0", "-np", "LABEL"}))
+	assert.Equal(t, "/tmp/ws", opts.WorkspaceDir)
+	assert.Equal(t, "3.8.0", opts.Kernel)
+	assert.True(t, opts.NoPull)
+	assert.Equal(t, 1, opts.Slot)
+	assert.Equal(t, []string{"LABEL"}, fs.Args())
+
+	assert.Equal(t, []string{"-c", "--color", "-d", "--directory", "-k", "--kernel", "--no-pull", "-np", "-o", "--output", "-v", "--verbose", "-y", "--yes"}, GetFlagNames(fs))
+	assert.False(t, opts.Yes)
+}
+
+func TestGetUsage(t *testing.T) {
+	usage := GetUsage()
+
+	for _, cmd := range Commands {
+		assert.Contains(t, usage, "\n    "+cmd.Name)
+	}
+	assert.Contains(t, usage, "    clone [--directory <PATH>] [--kernel <VERSION>] [--no-pull] [--yes]\n        <LABEL>\n")
+	assert.Contains(t, usage, "Version: "+Version)
+
+	for _, line := range strings.Split(usage, "\n") {
+		assert.LessOrEqual(t, len(line), 80, line)
+	}
+
+	assert.Nil(t, HelpCommand("b"))
+	assert.Equal(t, 301, GetErrorCode(HelpCommand("unknown")))
+}
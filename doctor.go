@@ -0,0 +1,411 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"os"
+	"os/exec"
+	"os/user"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Check is the result of a check of the environment
+type Check struct {
+	Name   string       `json:"name"`
+	Status string       `json:"status"` // "pass", "warn" or "fail"
+	Detail string       `json:"detail,omitempty"`
+	Hint   string       `json:"hint,omitempty"`
+	Fix    func() error `json:"-"` // the safe fix of the problem, nil if there is none
+}
+
+// ToolRequirement is a tool which must be installed, with the minimum version
+type ToolRequirement struct {
+	Name       string
+	MinVersion string
+	Hint       string // message ID of the hint
+}
+
+// RequiredTools are the tools checked by the doctor command.
+// Git 2.28.0 is the first version supporting 'git init --initial-branch'.
+var RequiredTools = []ToolRequirement{
+	{"git", "2.28.0", "hint-git"},
+	{"pros", "3.2.0", "hint-pros"},
+	{"arm-none-eabi-gcc", "9.2.0", "hint-gcc"},
+}
+
+// Matches the first version number like 2.39.0 or 10.3 in the output of '--version'
+var toolVersion = regexp.MustCompile(`[0-9]+\.[0-9]+(\.[0-9]+)?`)
+
+// ParseToolVersion returns the first version number in the output, or an empty string if there is none.
+// No side effect
+func ParseToolVersion(output string) string {
+	return toolVersion.FindString(output)
+}
+
+// CompareVersions returns -1, 0 or 1 if the version a is older than, the same as or newer than the version b.
+// Missing parts are treated as 0.
+// No side effect
+func CompareVersions(a string, b string) int {
+	as := strings.Split(a, ".")
+	bs := strings.Split(b, ".")
+	for i := 0; i < len(as) || i < len(bs); i++ {
+		x, _ := strconv.Atoi(GetArg(as, i))
+		y, _ := strconv.Atoi(GetArg(bs, i))
+		if x < y {
+			return -1
+		} else if x > y {
+			return 1
+		}
+	}
+	return 0
+}
+
+// GetExecutableName returns the file name of the executable on this platform.
+// No side effect
+func GetExecutableName(name string) string {
+	if runtime.GOOS == "windows" {
+		return name + ".exe"
+	}
+	return name
+}
+
+// GetShellRcPath returns the startup file of the shell, or an empty string if it is unknown.
+// No side effect
+func GetShellRcPath(home string, shell string) string {
+	if runtime.GOOS == "windows" || shell == "" {
+		return ""
+	}
+
+	switch filepath.Base(shell) {
+	case "zsh":
+		return filepath.Join(home, ".zshrc")
+	case "fish":
+		return filepath.Join(home, ".config", "fish", "config.fish")
+	case "bash":
+		if runtime.GOOS == "darwin" {
+			return filepath.Join(home, ".bash_profile")
+		}
+		return filepath.Join(home, ".bashrc")
+	default:
+		return filepath.Join(home, ".profile")
+	}
+}
+
+// GetExportLine returns the line of the shell startup file which sets the environment variable. The value
+// of PATH is prepended to it.
+// No side effect
+func GetExportLine(shell string, name string, value string) string {
+	if filepath.Base(shell) == "fish" {
+		if name == "PATH" {
+			return `fish_add_path "` + value + `"`
+		}
+		return "set -gx " + name + ` "` + value + `"`
+	}
+
+	if name == "PATH" {
+		return `export PATH="` + value + `:$PATH"`
+	}
+	return "export " + name + `="` + value + `"`
+}
+
+// AppendToShellRc appends the line to the shell startup file, unless the file has the line already.
+func AppendToShellRc(filename string, line string) error {
+	data, err := os.ReadFile(filename)
+	if err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	if strings.Contains(string(data), line) {
+		return nil
+	}
+
+	if err := os.MkdirAll(filepath.Dir(filename), os.ModePerm); err != nil {
+		return err
+	}
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	_, err = file.WriteString("\n# Added by cmapi-cli doctor\n" + line + "\n")
+	return err
+}
+
+// NewExportFix returns the fix which sets the environment variable in the shell startup file and in this
+// session, and its hint. Returns nil if the shell is unknown.
+func NewExportFix(name string, value string) (func() error, string) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return nil, ""
+	}
+	shell := os.Getenv("SHELL")
+	rc := GetShellRcPath(home, shell)
+	if rc == "" {
+		return nil, ""
+	}
+
+	line := GetExportLine(shell, name, value)
+	fix := func() error {
+		if err := AppendToShellRc(rc, line); err != nil {
+			return err
+		}
+		if name == "PATH" {
+			value += string(os.PathListSeparator) + os.Getenv("PATH")
+		}
+		return os.Setenv(name, value)
+	}
+	return fix, T("hint-export", line, rc)
+}
+
+// IsToolchainDir returns true if the directory contains the compiler of the PROS toolchain.
+// No side effect
+func IsToolchainDir(dir string) bool {
+	info, err := os.Stat(filepath.Join(dir, "bin", GetExecutableName("arm-none-eabi-gcc")))
+	return err == nil && !info.IsDir()
+}
+
+// CheckTool checks if the tool is installed and not older than the minimum version
+func CheckTool(tool ToolRequirement) Check {
+	check := Check{Name: tool.Name, Status: "fail", Hint: T(tool.Hint)}
+
+	out, _, code := RunCommandGetOutput(WorkingDir, tool.Name, "--version")
+	version := ParseToolVersion(out)
+	switch {
+	case code != 0:
+		check.Detail = T("check-tool-missing", tool.Name)
+
+		// The compiler is not in PATH, but the toolchain is found
+		toolchain := os.Getenv("PROS_TOOLCHAIN")
+		if tool.Name == "arm-none-eabi-gcc" && toolchain != "" && IsToolchainDir(toolchain) {
+			if fix, hint := NewExportFix("PATH", filepath.Join(toolchain, "bin")); fix != nil {
+				check.Fix, check.Hint = fix, hint
+			}
+		}
+	case version == "":
+		check.Status = "warn"
+		check.Detail = T("check-tool-unknown", tool.Name)
+	case CompareVersions(version, tool.MinVersion) < 0:
+		check.Detail = T("check-tool-old", tool.Name, version, tool.MinVersion)
+	default:
+		check.Status, check.Detail, check.Hint = "pass", version, ""
+	}
+
+	return check
+}
+
+// CheckToolchain checks if PROS_TOOLCHAIN is the directory of the PROS toolchain
+func CheckToolchain() Check {
+	toolchain := os.Getenv("PROS_TOOLCHAIN")
+	check := Check{Name: "PROS_TOOLCHAIN", Status: "fail", Hint: T("hint-toolchain")}
+
+	switch info, err := os.Stat(toolchain); {
+	case toolchain == "":
+		check.Detail = T("check-toolchain-unset")
+	case err != nil || !info.IsDir():
+		check.Detail = T("check-toolchain-missing", toolchain)
+	case !IsToolchainDir(toolchain):
+		check.Detail = T("check-toolchain-no-gcc", toolchain)
+	default:
+		return Check{Name: check.Name, Status: "pass", Detail: toolchain}
+	}
+
+	// The compiler is in PATH, its toolchain can be used
+	if path, err := exec.LookPath(GetExecutableName("arm-none-eabi-gcc")); err == nil {
+		if fix, hint := NewExportFix("PROS_TOOLCHAIN", filepath.Dir(filepath.Dir(path))); fix != nil {
+			check.Fix, check.Hint = fix, hint
+		}
+	}
+
+	return check
+}
+
+// CheckAdminDir checks if the administrator directory is writable
+func CheckAdminDir() Check {
+	check := Check{Name: "admin-dir", Status: "pass", Detail: AdminDir}
+
+	file, err := os.CreateTemp(AdminDir, ".cmapi-cli-doctor-*")
+	if AdminDir == "" || err != nil {
+		check.Status = "fail"
+		check.Detail = T("check-admin-dir", AdminDir)
+		check.Hint = T("hint-admin-dir", AdminDir)
+		return check
+	}
+	file.Close()
+	os.Remove(file.Name())
+
+	return check
+}
+
+// CheckSecretFile checks if the secret file can be parsed and the password is set
+func CheckSecretFile() Check {
+	check := Check{Name: "secret", Status: "pass", Detail: SecretFilePath}
+
+	if ReadJson(SecretFilePath) == nil {
+		check.Status = "fail"
+		check.Detail = T("check-secret-invalid", SecretFilePath)
+		check.Hint = T("hint-secret-invalid")
+	} else if Secret["password"] == "" {
+		check.Status = "warn"
+		check.Detail = T("check-password-empty")
+		check.Hint = T("hint-password")
+	}
+
+	return check
+}
+
+// CheckCredentials checks if the credentials in the secret can access the workspace with the Bitbucket API
+func CheckCredentials() Check {
+	check := Check{Name: "bitbucket", Status: "fail"}
+
+	url := BitbucketApiUrl + "/repositories/" + Secret["workspace"] + "?pagelen=1&fields=size"
+	req, err := NewBitbucketRequest("GET", url, nil)
+	if err != nil {
+		check.Detail = T("check-api-offline")
+		check.Hint = T("hint-network")
+		return check
+	}
+
+	client := &http.Client{Timeout: 10 * time.Second}
+	res, err := client.Do(req)
+	if err != nil {
+		check.Detail = T("check-api-offline")
+		check.Hint = T("hint-network")
+		return check
+	}
+	res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		check.Detail = T("check-api-status", res.Status)
+		check.Hint = T("hint-credentials")
+		return check
+	}
+
+	check.Status = "pass"
+	check.Detail = Secret["username"] + " @ " + Secret["workspace"]
+	return check
+}
+
+// CheckSerialDevices checks if the user can access the serial devices of the V5 Brain and controller
+func CheckSerialDevices() Check {
+	check := Check{Name: "serial", Status: "fail"}
+
+	if runtime.GOOS == "linux" {
+		if usr, err := user.Current(); err == nil {
+			if ok, err := IsInDialoutGroup(usr); err == nil && !ok {
+				check.Detail = T("check-dialout")
+				check.Hint = T("hint-dialout")
+				return check
+			}
+		}
+	}
+
+	if SerialDevicePatterns == nil {
+		check.Status = "pass"
+		check.Detail = T("check-not-checked")
+		return check
+	}
+
+	devices := []string{}
+	for _, pattern := range SerialDevicePatterns {
+		matches, _ := filepath.Glob(pattern)
+		devices = append(devices, matches...)
+	}
+
+	if len(devices) == 0 {
+		check.Status = "warn"
+		check.Detail = T("check-no-device")
+		check.Hint = T("hint-connect")
+		return check
+	}
+
+	for _, device := range devices {
+		if !IsDeviceAccessible(device) {
+			check.Detail = T("check-device-denied", device)
+			check.Hint = T("hint-device")
+			return check
+		}
+	}
+
+	check.Status = "pass"
+	check.Detail = T("check-devices", len(devices))
+	return check
+}
+
+// RunChecks runs all checks of the environment
+func RunChecks() []Check {
+	checks := []Check{}
+	for _, tool := range RequiredTools {
+		checks = append(checks, CheckTool(tool))
+	}
+	return append(checks, CheckToolchain(), CheckAdminDir(), CheckSecretFile(), CheckCredentials(),
+		CheckSerialDevices())
+}
+
+// PrintChecks prints the result and the hint of each check, and the summary
+func PrintChecks(checks []Check) {
+	counts := map[string]int{}
+	for _, check := range checks {
+		counts[check.Status]++
+
+		line := fmt.Sprintf("%-7s %-18s %s", "["+check.Status+"]", check.Name, check.Detail)
+		switch check.Status {
+		case "pass":
+			fmt.Fprintln(Console, SuccessText(line))
+		case "warn":
+			fmt.Fprintln(Console, WarningText(line))
+		default:
+			fmt.Fprintln(Console, ErrorText(line))
+		}
+
+		if check.Hint != "" {
+			fmt.Fprintln(Console, strings.Repeat(" ", 8)+T("doctor-hint", check.Hint))
+		}
+	}
+
+	fmt.Fprintln(Console, InfoText(T("doctor-summary", counts["pass"], counts["warn"], counts["fail"])))
+}
+
+// DoctorCommand checks the environment and shows how to fix the problems found. The safe fixes are applied
+// if fix is true.
+func DoctorCommand(fix bool) error {
+	checks := RunChecks()
+
+	if fix {
+		fixed := 0
+		for _, check := range checks {
+			if check.Status == "pass" || check.Fix == nil {
+				continue
+			}
+			if err := check.Fix(); err != nil {
+				ReportError(WrapError(err, 161, check.Name))
+			} else {
+				fixed++
+				fmt.Fprintln(Console, SuccessText(T("doctor-fixed", check.Name)))
+			}
+		}
+
+		if fixed != 0 {
+			checks = RunChecks()
+		}
+	}
+
+	ReportData("checks", checks)
+	PrintChecks(checks)
+
+	failed := 0
+	for _, check := range checks {
+		if check.Status == "fail" {
+			failed++
+		}
+	}
+	if failed != 0 {
+		return NewError(160, failed)
+	}
+
+	return nil
+}
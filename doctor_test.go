@@ -0,0 +1,191 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestToolVersions(t *testing.T) {
+	assert.Equal(t, "2.39.0", ParseToolVersion("git version 2.39.0\n"))
+	assert.Equal(t, "3.4.3", ParseToolVersion("pros, version 3.4.3"))
+	assert.Equal(t, "10.3", ParseToolVersion("arm-none-eabi-gcc (GNU Arm Embedded Toolchain 10.3-2021.10) 10.3.1"))
+	assert.Equal(t, "", ParseToolVersion("unknown"))
+
+	assert.Equal(t, 0, CompareVersions("2.28.0", "2.28.0"))
+	assert.Equal(t, 0, CompareVersions("10.3", "10.3.0"))
+	assert.Equal(t, -1, CompareVersions("2.9.5", "2.28.0"))
+	assert.Equal(t, 1, CompareVersions("10.3", "9.2.0"))
+}
+
+func TestShellRc(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no shell startup file on Windows")
+	}
+
+	assert.Equal(t, filepath.Join("/home/u", ".zshrc"), GetShellRcPath("/home/u", "/bin/zsh"))
+	assert.Equal(t, filepath.Join("/home/u", ".config", "fish", "config.fish"), GetShellRcPath("/home/u", "/usr/bin/fish"))
+	assert.Equal(t, filepath.Join("/home/u", ".profile"), GetShellRcPath("/home/u", "/bin/sh"))
+	assert.Equal(t, "", GetShellRcPath("/home/u", ""))
+
+	assert.Equal(t, `export PROS_TOOLCHAIN="/opt/pros"`, GetExportLine("/bin/bash", "PROS_TOOLCHAIN", "/opt/pros"))
+	assert.Equal(t, `export PATH="/opt/pros/bin:$PATH"`, GetExportLine("/bin/bash", "PATH", "/opt/pros/bin"))
+	assert.Equal(t, `set -gx PROS_TOOLCHAIN "/opt/pros"`, GetExportLine("/usr/bin/fish", "PROS_TOOLCHAIN", "/opt/pros"))
+	assert.Equal(t, `fish_add_path "/opt/pros/bin"`, GetExportLine("/usr/bin/fish", "PATH", "/opt/pros/bin"))
+
+	rc := filepath.Join(t.TempDir(), ".config", "fish", "config.fish")
+	assert.Nil(t, AppendToShellRc(rc, "set -gx A 1"))
+	assert.Nil(t, AppendToShellRc(rc, "set -gx A 1"))
+	data, _ := os.ReadFile(rc)
+	assert.Equal(t, "\n# Added by cmapi-cli doctor\nset -gx A 1\n", string(data))
+}
+
+func TestNewExportFix(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no shell startup file on Windows")
+	}
+
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("SHELL", "/bin/zsh")
+	t.Setenv("PROS_TOOLCHAIN", "")
+
+	fix, hint := NewExportFix("PROS_TOOLCHAIN", "/opt/pros")
+	assert.Contains(t, hint, filepath.Join(home, ".zshrc"))
+	assert.Nil(t, fix())
+	assert.Equal(t, "/opt/pros", os.Getenv("PROS_TOOLCHAIN"))
+
+	data, _ := os.ReadFile(filepath.Join(home, ".zshrc"))
+	assert.Contains(t, string(data), `export PROS_TOOLCHAIN="/opt/pros"`)
+
+	t.Setenv("SHELL", "")
+	fix, _ = NewExportFix("PROS_TOOLCHAIN", "/opt/pros")
+	assert.Nil(t, fix)
+}
+
+func TestCheckTool(t *testing.T) {
+	setup()
+	defer teardown()
+
+	t.Setenv("PROS_TOOLCHAIN", "")
+
+	MockCommandsQueue = []CommandSpec{
+		{"git --version", "git version 2.39.0\n", "", 0},
+		{"git --version", "git version 2.17.1\n", "", 0},
+		{"git --version", "git version unknown\n", "", 0},
+		{"arm-none-eabi-gcc --version", "", "", 127},
+	}
+
+	git := RequiredTools[0]
+	assert.Equal(t, Check{Name: "git", Status: "pass", Detail: "2.39.0"}, CheckTool(git))
+
+	check := CheckTool(git)
+	assert.Equal(t, "fail", check.Status)
+	assert.Equal(t, "git 2.17.1 is older than 2.28.0.", check.Detail)
+	assert.Equal(t, T("hint-git"), check.Hint)
+
+	assert.Equal(t, "warn", CheckTool(git).Status)
+
+	check = CheckTool(RequiredTools[2])
+	assert.Equal(t, "fail", check.Status)
+	assert.Equal(t, "arm-none-eabi-gcc is not installed or not in PATH.", check.Detail)
+	assert.Nil(t, check.Fix)
+}
+
+func TestCheckToolchain(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv("PATH", dir)
+
+	t.Setenv("PROS_TOOLCHAIN", "")
+	assert.Equal(t, T("check-toolchain-unset"), CheckToolchain().Detail)
+
+	t.Setenv("PROS_TOOLCHAIN", filepath.Join(dir, "missing"))
+	assert.Equal(t, T("check-toolchain-missing", filepath.Join(dir, "missing")), CheckToolchain().Detail)
+
+	t.Setenv("PROS_TOOLCHAIN", dir)
+	check := CheckToolchain()
+	assert.Equal(t, "fail", check.Status)
+	assert.Equal(t, T("check-toolchain-no-gcc", dir), check.Detail)
+
+	os.MkdirAll(filepath.Join(dir, "bin"), os.ModePerm)
+	os.WriteFile(filepath.Join(dir, "bin", GetExecutableName("arm-none-eabi-gcc")), []byte{}, 0755)
+	assert.Equal(t, Check{Name: "PROS_TOOLCHAIN", Status: "pass", Detail: dir}, CheckToolchain())
+}
+
+func TestCheckAdminDirAndSecret(t *testing.T) {
+	setup()
+	defer teardown()
+
+	AdminDir = t.TempDir()
+	defer func() { AdminDir = "" }()
+	assert.Equal(t, "pass", CheckAdminDir().Status)
+
+	AdminDir = filepath.Join(AdminDir, "missing")
+	assert.Equal(t, "fail", CheckAdminDir().Status)
+
+	password := Secret["password"]
+	SecretFilePath = filepath.Join(t.TempDir(), ".cmapi-cli-secret.json")
+	defer func() {
+		Secret["password"] = password
+		SecretFilePath = ""
+	}()
+
+	os.WriteFile(SecretFilePath, []byte("{"), 0600)
+	assert.Equal(t, "fail", CheckSecretFile().Status)
+
+	WriteJson(SecretFilePath, Secret)
+	Secret["password"] = ""
+	assert.Equal(t, "warn", CheckSecretFile().Status)
+	Secret["password"] = "hunter2"
+	assert.Equal(t, "pass", CheckSecretFile().Status)
+}
+
+func TestCheckCredentials(t *testing.T) {
+	status := http.StatusOK
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/repositories/"+Secret["workspace"], r.URL.Path)
+		w.WriteHeader(status)
+	}))
+
+	original := BitbucketApiUrl
+	BitbucketApiUrl = server.URL
+	defer func() { BitbucketApiUrl = original }()
+
+	assert.Equal(t, "pass", CheckCredentials().Status)
+
+	status = http.StatusUnauthorized
+	check := CheckCredentials()
+	assert.Equal(t, "fail", check.Status)
+	assert.Equal(t, T("check-api-status", "401 Unauthorized"), check.Detail)
+	assert.Equal(t, T("hint-credentials"), check.Hint)
+
+	server.Close()
+	assert.Equal(t, T("check-api-offline"), CheckCredentials().Detail)
+}
+
+func TestCheckSerialDevices(t *testing.T) {
+	if SerialDevicePatterns == nil {
+		t.Skip("serial devices are not listed on this platform")
+	}
+
+	original := SerialDevicePatterns
+	defer func() { SerialDevicePatterns = original }()
+
+	dir := t.TempDir()
+	SerialDevicePatterns = []string{filepath.Join(dir, "ttyACM*")}
+
+	check := CheckSerialDevices()
+	if check.Detail == T("check-dialout") {
+		t.Skip("the user is not in the dialout group")
+	}
+	assert.Equal(t, "warn", check.Status)
+
+	os.WriteFile(filepath.Join(dir, "ttyACM0"), []byte{}, 0600)
+	os.WriteFile(filepath.Join(dir, "ttyACM1"), []byte{}, 0600)
+	assert.Equal(t, Check{Name: "serial", Status: "pass", Detail: "2 device(s) found."}, CheckSerialDevices())
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// CliError is an error with a code in ErrorCode, the arguments of the message and the cause if any
+type CliError struct {
+	Code  int
+	Args  []any
+	Cause error
+}
+
+// Verbose is true if the causes of errors should be printed
+var Verbose = false
+
+// Error returns the message of the error code in the current language formatted with the arguments,
+// without the cause
+func (e *CliError) Error() string {
+	return GetErrorMessage(e.Code, e.Args...)
+}
+
+// Unwrap returns the cause of the error
+func (e *CliError) Unwrap() error {
+	return e.Cause
+}
+
+// NewError returns an error with the given code and arguments of the message.
+// No side effect
+func NewError(code int, args ...any) error {
+	return &CliError{Code: code, Args: args}
+}
+
+// WrapError returns an error with the given code and arguments of the message, caused by the given error.
+// No side effect
+func WrapError(cause error, code int, args ...any) error {
+	return &CliError{Code: code, Args: args, Cause: cause}
+}
+
+// GetErrorCode returns the code of the outermost CliError in the chain, or 0 if there is none.
+// No side effect
+func GetErrorCode(err error) int {
+	var cliErr *CliError
+	if errors.As(err, &cliErr) {
+		return cliErr.Code
+	}
+	return 0
+}
+
+// GetErrorCauses returns the messages of the causes of the error, from the outermost to the innermost.
+// No side effect
+func GetErrorCauses(err error) []string {
+	rtn := []string{}
+
+	var cliErr *CliError
+	for errors.As(err, &cliErr) && cliErr.Cause != nil {
+		err = cliErr.Cause
+		rtn = append(rtn, err.Error())
+		if _, ok := err.(*CliError); !ok {
+			// The message of other errors includes their causes
+			break
+		}
+	}
+
+	return rtn
+}
+
+// GetExitError returns an error if the exit code of the command is not 0.
+// Only the program and its sub-command are mentioned as the other arguments may contain credentials.
+// No side effect
+func GetExitError(code int, name string, arg ...string) error {
+	if code == 0 {
+		return nil
+	}
+	if len(arg) != 0 && !strings.HasPrefix(arg[0], "-") {
+		name += " " + arg[0]
+	}
+	return fmt.Errorf("'%s' exited with code %d", name, code)
+}
+
+// ReportError prints the error with its code, and the causes if verbose.
+// The error is also recorded in the result of the running command.
+// Returns false for convenience
+func ReportError(err error) bool {
+	code := GetErrorCode(err)
+	causes := GetErrorCauses(err)
+	recordError(code, err.Error(), causes)
+
+	if code == 0 {
+		fmt.Fprintln(Console, ErrorText(T("error", err)))
+	} else {
+		fmt.Fprintln(Console, ErrorText(T("error-with-code", code, err)))
+	}
+
+	if Verbose {
+		for _, cause := range causes {
+			fmt.Fprintln(Console, ErrorText(T("caused-by", cause)))
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// HistoryEntry is a record of a build or upload run.
+type HistoryEntry struct {
+	Timestamp      time.Time `json:"timestamp"`
+	Command        string    `json:"command"`
+	Project        string    `json:"project"`
+	Commit         string    `json:"commit,omitempty"`
+	Slot           int       `json:"slot,omitempty"`
+	MakeSeconds    float64   `json:"make-seconds"`
+	UploadSeconds  float64   `json:"upload-seconds,omitempty"`
+	Retries        int       `json:"retries"`
+	MakeExitCode   int       `json:"make-exit-code"`
+	UploadExitCode int       `json:"upload-exit-code"`
+	Success        bool      `json:"success"`
+}
+
+// HistoryStats is the summary of the runs of a project.
+type HistoryStats struct {
+	Project          string
+	Runs             int
+	Failures         int
+	Uploads          int
+	AvgMakeSeconds   float64
+	AvgUploadSeconds float64
+}
+
+// GetHistoryFilePath returns the path of the history file in the administrator directory.
+// No side effect
+func GetHistoryFilePath() string {
+	return filepath.Join(AdminDir, ".cmapi-cli-history.jsonl")
+}
+
+// NewHistoryEntry returns a history entry of the project at the current commit.
+func NewHistoryEntry(projectRoot string, command string, slot int) HistoryEntry {
+	commit, _, _ := RunCommandGetOutput(projectRoot, "git", "rev-parse", "--short", "HEAD")
+
+	return HistoryEntry{
+		Timestamp: time.Now(),
+		Command:   command,
+		Project:   projectRoot,
+		Commit:    strings.TrimSpace(commit),
+		Slot:      slot,
+	}
+}
+
+// AppendHistory appends the entry to the history file as a JSON line.
+// No side effect
+func AppendHistory(filename string, entry HistoryEntry) bool {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return false
+	}
+
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0600)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	_, err = file.Write(append(data, '\n'))
+	return err == nil
+}
+
+// ReadHistory reads all entries in the history file. Malformed lines are skipped.
+// No side effect
+func ReadHistory(filename string) []HistoryEntry {
+	rtn := []HistoryEntry{}
+
+	file, err := os.Open(filename)
+	if err != nil {
+		return rtn
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var entry HistoryEntry
+		if json.Unmarshal(scanner.Bytes(), &entry) == nil {
+			rtn = append(rtn, entry)
+		}
+	}
+
+	return rtn
+}
+
+// RecordHistory appends the entry to the history file in the administrator directory.
+// The history is best-effort, a failure does not affect the command.
+// The entry is also reported in the result of the running command.
+func RecordHistory(entry HistoryEntry) bool {
+	ReportData("run", entry)
+
+	if AdminDir == "" {
+		return false
+	}
+	return AppendHistory(GetHistoryFilePath(), entry)
+}
+
+// GetHistoryStats returns the summary of each project, sorted by project.
+// No side effect
+func GetHistoryStats(entries []HistoryEntry) []HistoryStats {
+	statsMap := map[string]*HistoryStats{}
+
+	for _, entry := range entries {
+		stats, ok := statsMap[entry.Project]
+		if !ok {
+			stats = &HistoryStats{Project: entry.Project}
+			statsMap[entry.Project] = stats
+		}
+
+		stats.Runs++
+		if !entry.Success {
+			stats.Failures++
+		}
+		// Sum up first, divided below
+		stats.AvgMakeSeconds += entry.MakeSeconds
+		if entry.UploadSeconds != 0 {
+			stats.Uploads++
+			stats.AvgUploadSeconds += entry.UploadSeconds
+		}
+	}
+
+	rtn := []HistoryStats{}
+	for _, stats := range statsMap {
+		stats.AvgMakeSeconds /= float64(stats.Runs)
+		if stats.Uploads != 0 {
+			stats.AvgUploadSeconds /= float64(stats.Uploads)
+		}
+		rtn = append(rtn, *stats)
+	}
+	sort.Slice(rtn, func(i, j int) bool {
+		return rtn[i].Project < rtn[j].Project
+	})
+
+	return rtn
+}
+
+// HistoryCommand shows the most recent runs and the average durations of each project
+func HistoryCommand(count int) error {
+	entries := ReadHistory(GetHistoryFilePath())
+	if len(entries) == 0 {
+		return Success("history-empty")
+	}
+
+	fmt.Fprintln(Console, HeaderText(T("history-recent")))
+
+	recent := entries
+	if len(recent) > count {
+		recent = recent[len(recent)-count:]
+	}
+	ReportData("entries", recent)
+	ReportData("stats", GetHistoryStats(entries))
+	for _, entry := range recent {
+		result := T("history-ok")
+		if !entry.Success {
+			result = T("history-failed", entry.MakeExitCode, entry.UploadExitCode)
+		}
+
+		line := fmt.Sprintf("%s  %-20s %-8s %-6s make %6.1fs",
+			entry.Timestamp.Local().Format("2006-01-02 15:04:05"), filepath.Base(entry.Project),
+			entry.Commit, entry.Command, entry.MakeSeconds)
+		if entry.Slot != 0 {
+			line += fmt.Sprintf("  upload %6.1fs  slot %d  retries %d", entry.UploadSeconds, entry.Slot, entry.Retries)
+		}
+		fmt.Fprintln(Console, line+"  "+result)
+	}
+
+	fmt.Fprintln(Console)
+	fmt.Fprintln(Console, HeaderText(T("history-averages")))
+
+	for _, stats := range GetHistoryStats(entries) {
+		fmt.Fprintf(Console, "%-20s runs %3d  failures %3d  make %6.1fs  upload %6.1fs\n",
+			filepath.Base(stats.Project), stats.Runs, stats.Failures, stats.AvgMakeSeconds, stats.AvgUploadSeconds)
+	}
+
+	return nil
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadWriteHistory(t *testing.T) {
+	defer os.Remove("history.jsonl")
+
+	assert.Empty(t, ReadHistory("history.jsonl"))
+
+	a := HistoryEntry{Timestamp: time.Unix(1000, 0).UTC(), Command: "b", Project: "/a", MakeSeconds: 3, Success: true}
+	b := HistoryEntry{Timestamp: time.Unix(2000, 0).UTC(), Command: "normal", Project: "/b", Commit: "abc1234",
+		Slot: 2, MakeSeconds: 5, UploadSeconds: 8, Retries: 1, UploadExitCode: 0, Success: true}
+
+	assert.True(t, AppendHistory("history.jsonl", a))
+	assert.True(t, AppendHistory("history.jsonl", b))
+
+	f, _ := os.OpenFile("history.jsonl", os.O_APPEND|os.O_WRONLY, 0600)
+	f.WriteString("not json\n")
+	f.Close()
+
+	assert.Equal(t, []HistoryEntry{a, b}, ReadHistory("history.jsonl"))
+}
+
+func TestGetHistoryStats(t *testing.T) {
+	entries := []HistoryEntry{
+		{Project: "/b", MakeSeconds: 4, UploadSeconds: 10, Success: true},
+		{Project: "/a", MakeSeconds: 2, Success: true},
+		{Project: "/b", MakeSeconds: 6, MakeExitCode: 2},
+		{Project: "/b", MakeSeconds: 8, UploadSeconds: 20, Success: true},
+	}
+
+	assert.Equal(t, []HistoryStats{
+		{Project: "/a", Runs: 1, AvgMakeSeconds: 2},
+		{Project: "/b", Runs: 3, Failures: 1, Uploads: 2, AvgMakeSeconds: 6, AvgUploadSeconds: 15},
+	}, GetHistoryStats(entries))
+}
+
+func TestCompileCommandRecordsHistory(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	AdminDir = wd
+	defer os.Remove(GetHistoryFilePath())
+
+	os.WriteFile("project.pros", []byte("any"), 0644)
+
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse --short HEAD", "abc1234\n", "", 0},
+		{"make -j", "", "", 0},
+		{"pros lsusb --target v5", "VEX EDR V5 System Port - ttyACM0", "", 0},
+		{"pros upload --after screen --slot 3", "", "", 1},
+		{"pros upload --after screen --slot 3", "", "", 0},
+	}
+
+	assert.Nil(t, CompileCommand(wd, false, 3))
+
+	entries := ReadHistory(GetHistoryFilePath())
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "normal", entries[0].Command)
+	assert.Equal(t, wd, entries[0].Project)
+	assert.Equal(t, "abc1234", entries[0].Commit)
+	assert.Equal(t, 3, entries[0].Slot)
+	assert.Equal(t, 1, entries[0].Retries)
+	assert.True(t, entries[0].Success)
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+)
+
+// GetHookScriptPath returns the path of the hook script in the project, or an empty string if not found.
+// No side effect
+func GetHookScriptPath(projectRoot string, hook string) string {
+	candidates := []string{hook}
+	if runtime.GOOS == "windows" {
+		candidates = []string{hook + ".bat", hook + ".cmd"}
+	}
+
+	for _, candidate := range candidates {
+		path := filepath.Join(projectRoot, ".cmapi", "hooks", candidate)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() {
+			return path
+		}
+	}
+
+	return ""
+}
+
+// GetHookCommands returns the command lines to run for the hook, from the profile, the project config and
+// the hook script in the project, in that order.
+// No side effect
+func GetHookCommands(projectRoot string, hook string) [][]string {
+	rtn := [][]string{}
+
+	if commandLine := Secret["hook-"+hook]; commandLine != "" {
+		rtn = append(rtn, GetShellCommand(commandLine))
+	}
+
+	if config := ReadProjectConfig(projectRoot); config != nil && config.Hooks[hook] != "" {
+		rtn = append(rtn, GetShellCommand(config.Hooks[hook]))
+	}
+
+	if path := GetHookScriptPath(projectRoot, hook); path != "" {
+		if runtime.GOOS == "windows" {
+			rtn = append(rtn, []string{"cmd", "/C", path})
+		} else if info, _ := os.Stat(path); info.Mode().Perm()&0111 != 0 {
+			rtn = append(rtn, []string{path})
+		} else {
+			rtn = append(rtn, []string{"sh", path})
+		}
+	}
+
+	return rtn
+}
+
+// GetShellCommand returns the command to run the command line with the system shell.
+// No side effect
+func GetShellCommand(commandLine string) []string {
+	if runtime.GOOS == "windows" {
+		return []string{"cmd", "/C", commandLine}
+	}
+	return []string{"sh", "-c", commandLine}
+}
+
+// GetHookEnv returns the environment variables passed to the hooks. Hooks come with the project, so they
+// never see the credentials: the password is hidden and the path of the profile is left out.
+// No side effect
+func GetHookEnv(projectRoot string, hook string, command string) []string {
+	rtn := []string{}
+	for _, env := range GetPluginEnv(projectRoot, RedactSecrets(Secret)) {
+		if !strings.HasPrefix(env, "CMAPI_CLI_PROFILE=") {
+			rtn = append(rtn, env)
+		}
+	}
+	return append(rtn, "CMAPI_CLI_HOOK="+hook, "CMAPI_CLI_COMMAND="+command)
+}
+
+// RunHooks runs all commands of the hook in the project root with the context in the environment variables.
+// Returns the error of the first failed command
+func RunHooks(projectRoot string, hook string, command string, extraEnv ...string) error {
+	for _, argv := range GetHookCommands(projectRoot, hook) {
+		fmt.Fprintln(Console, InfoText(T("hook-running", hook)))
+
+		cmd := ExecCommand(argv[0], argv[1:]...)
+		cmd.Dir = projectRoot
+		if cmd.Env == nil {
+			cmd.Env = os.Environ()
+		}
+		cmd.Env = append(cmd.Env, GetHookEnv(projectRoot, hook, command)...)
+		cmd.Env = append(cmd.Env, extraEnv...)
+
+		cmd.Stdout, cmd.Stderr = GetCommandOutput(projectRoot)
+
+		code := RunCommand(cmd)
+		FixConsoleColor()
+
+		if err := GetExitError(code, argv[0], argv[1:]...); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// RunPreHooks runs the pre-hook, the command should be aborted if it returns an error
+func RunPreHooks(projectRoot string, hook string, command string, extraEnv ...string) error {
+	if err := RunHooks(projectRoot, hook, command, extraEnv...); err != nil {
+		BeepFail()
+		return WrapError(err, 156, hook)
+	}
+	return nil
+}
+
+// RunPostHooks runs the post-hook with the result of the command. A failure is reported but does not change
+// the result of the command
+func RunPostHooks(projectRoot string, hook string, command string, success bool, extraEnv ...string) {
+	extraEnv = append(extraEnv, "CMAPI_CLI_SUCCESS="+strconv.FormatBool(success))
+	if err := RunHooks(projectRoot, hook, command, extraEnv...); err != nil {
+		ReportError(WrapError(err, 157, hook))
+	}
+}
@@ -0,0 +1,110 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetHookCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run with cmd on Windows")
+	}
+
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	assert.Empty(t, GetHookCommands(wd, "pre-build"))
+
+	Secret["hook-pre-build"] = "make format"
+	defer func() { Secret["hook-pre-build"] = "" }()
+	WriteProjectConfig(wd, &ProjectConfig{Hooks: map[string]string{"pre-build": "./codegen.sh"}})
+
+	script := filepath.Join(wd, ".cmapi", "hooks", "pre-build")
+	os.MkdirAll(filepath.Dir(script), os.ModePerm)
+	os.WriteFile(script, []byte("#!/bin/sh\n"), 0644)
+
+	assert.Equal(t, [][]string{
+		{"sh", "-c", "make format"},
+		{"sh", "-c", "./codegen.sh"},
+		{"sh", script},
+	}, GetHookCommands(wd, "pre-build"))
+
+	os.Chmod(script, 0755)
+	assert.Equal(t, []string{script}, GetHookCommands(wd, "pre-build")[2])
+}
+
+func TestGetHookEnv(t *testing.T) {
+	wd, _ := os.Getwd()
+
+	password := Secret["password"]
+	Secret["password"] = "hunter2"
+	defer func() { Secret["password"] = password }()
+
+	env := GetHookEnv(wd, "pre-build", "b")
+	assert.Contains(t, env, "CMAPI_CLI_PROJECT_ROOT="+wd)
+	assert.Contains(t, env, "CMAPI_CLI_HOOK=pre-build")
+	assert.Contains(t, env, "CMAPI_CLI_COMMAND=b")
+	assert.NotContains(t, strings.Join(env, "\n"), "hunter2")
+	assert.NotContains(t, strings.Join(env, "\n"), "CMAPI_CLI_PROFILE=")
+}
+
+func TestBackupCommandWithHook(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("hooks run with cmd on Windows")
+	}
+
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	WriteProjectConfig(wd, &ProjectConfig{Hooks: map[string]string{"pre-backup": "clang-format -i src/main.cpp"}})
+
+	MockCommandsQueue = []CommandSpec{
+		// the hook failed, aborted
+		{"git rev-parse", "", "", 0},
+		{"sh -c clang-format -i src/main.cpp", "", "", 1},
+		// success
+		{"git rev-parse", "", "", 0},
+		{"sh -c clang-format -i src/main.cpp", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 0},
+		{"git push -u origin master", "", "", 0},
+	}
+
+	assert.Equal(t, 156, GetErrorCode(BackupCommand(wd)))
+	assert.Nil(t, BackupCommand(wd))
+	assert.Empty(t, MockCommandsQueue)
+}
+
+func TestCommitChangedFiles(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	MockCommandsQueue = []CommandSpec{
+		// nothing changed by the hook
+		{"git status --porcelain", "", "", 0},
+		// changed
+		{"git status --porcelain", " M src/main.cpp\n", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 0},
+		// failed to commit
+		{"git status --porcelain", " M src/main.cpp\n", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 1},
+	}
+
+	assert.Nil(t, CommitChangedFiles(wd))
+	assert.Nil(t, CommitChangedFiles(wd))
+	assert.Equal(t, 105, GetErrorCode(CommitChangedFiles(wd)))
+	assert.Empty(t, MockCommandsQueue)
+}
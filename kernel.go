@@ -0,0 +1,197 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// Returns true if the given kernel version is valid
+// No side effect
+var IsValidKernelVersion = regexp.MustCompile(`^[0-9]+\.[0-9]+\.[0-9]+$`).MatchString
+
+// GetInstalledTemplates returns the name and version of the templates installed in the PROS project.
+// Returns nil if the project file cannot be read.
+// No side effect
+func GetInstalledTemplates(projectRoot string) map[string]string {
+	project, err := ReadProsProject(projectRoot)
+	if err != nil {
+		return nil
+	}
+
+	rtn := map[string]string{}
+	for name, template := range project.Templates {
+		rtn[name] = template.Version
+	}
+	return rtn
+}
+
+// GetInstalledKernel returns the kernel version installed in the PROS project, or an empty string if unknown.
+// No side effect
+func GetInstalledKernel(projectRoot string) string {
+	return GetInstalledTemplates(projectRoot)["kernel"]
+}
+
+// GetKernelWarning returns a warning if the installed kernel does not match the pinned version.
+// Returns an empty string if no kernel is pinned or the versions match.
+// No side effect
+func GetKernelWarning(projectRoot string) string {
+	config := ReadProjectConfig(projectRoot)
+	if config == nil || config.Kernel == "" {
+		return ""
+	}
+
+	installed := GetInstalledKernel(projectRoot)
+	if installed == config.Kernel {
+		return ""
+	}
+	if installed == "" {
+		installed = T("kernel-unknown")
+	}
+
+	return T("kernel-mismatch", GetErrorMessage(142, installed, config.Kernel))
+}
+
+// WarnKernelMismatch prints a warning if the installed kernel does not match the pinned version.
+func WarnKernelMismatch(projectRoot string) {
+	if warning := GetKernelWarning(projectRoot); warning != "" {
+		fmt.Fprintln(Console, WarningText(warning))
+	}
+}
+
+// KernelCommand lists, pins, upgrades or checks the PROS kernel of the project
+func KernelCommand(projectRoot string, action string, version string, noPull bool) error {
+	if !IsProsProject(projectRoot) {
+		return NewError(134)
+	}
+
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+
+	switch action {
+	case "", "check":
+		installed := GetInstalledKernel(projectRoot)
+		if installed == "" {
+			installed = "unknown"
+		}
+		pinned := config.Kernel
+		if pinned == "" {
+			pinned = "none"
+		}
+		ReportData("installed", installed)
+		ReportData("pinned", pinned)
+		fmt.Fprintln(Console, InfoText(T("status-kernel-installed"))+installed)
+		fmt.Fprintln(Console, InfoText(T("status-kernel-pinned"))+pinned)
+
+		if config.Kernel != "" && GetInstalledKernel(projectRoot) != config.Kernel {
+			return NewError(142, installed, config.Kernel)
+		}
+		return nil
+	case "list":
+		// The depots the kernels come from, then the kernels in them, which
+		// are read from the local cache only with --no-pull
+		if err := RunCommandGetError(projectRoot, "pros", "conductor", "query-depots"); err != nil {
+			return WrapError(err, 143)
+		}
+		args := []string{"conductor", "query-templates", "kernel", "--target", "v5"}
+		if noPull {
+			args = append(args, "--offline-only")
+		}
+		if err := RunCommandGetError(projectRoot, "pros", args...); err != nil {
+			return WrapError(err, 143)
+		}
+		return nil
+	case "pin":
+		if !IsValidKernelVersion(version) {
+			return NewError(203, version)
+		}
+		config.Kernel = version
+		if err := WriteProjectConfig(projectRoot, config); err != nil {
+			return WrapError(err, 144)
+		}
+		WarnKernelMismatch(projectRoot)
+		return Success("kernel-pinned", version)
+	case "upgrade":
+		return UpgradeKernel(projectRoot, config, version, noPull)
+	default:
+		return NewError(201, action)
+	}
+}
+
+// UpgradeKernel applies the kernel to the project and builds it. The project is rolled back if the build fails.
+// The pinned version is used if no version is given, otherwise the latest version is applied.
+func UpgradeKernel(projectRoot string, config *ProjectConfig, version string, noPull bool) error {
+	if !IsGitRepo(projectRoot) {
+		return NewError(102)
+	}
+
+	if version == "" {
+		version = config.Kernel
+	}
+	if version == "" {
+		version = "latest"
+	} else if !IsValidKernelVersion(version) {
+		return NewError(203, version)
+	}
+
+	changes, _, code := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain")
+	if code != 0 {
+		return WrapError(GetExitError(code, "git", "status"), 102)
+	}
+	if strings.TrimSpace(changes) != "" {
+		return NewError(145)
+	}
+
+	fmt.Fprintln(Console, HeaderText("------------------ Apply Kernel ------------------"))
+
+	args := []string{"conductor", "apply", "kernel@" + version, "--force-apply"}
+	if noPull {
+		args = append(args, "--no-download")
+	}
+	out, errs, code := RunCommandPrintOut(projectRoot, "pros", args...)
+	if strings.Contains(out, "ERROR") || strings.Contains(errs, "ERROR") || code != 0 {
+		if rollbackErr := RollbackProject(projectRoot); rollbackErr != nil {
+			return WrapError(rollbackErr, 193, version)
+		}
+		return WrapError(GetExitError(code, "pros", args...), 146, version)
+	}
+
+	fmt.Fprintln(Console, HeaderText("------------------ Make Project ------------------"))
+
+	if err := RunCommandGetError(projectRoot, "make", "-j"); err != nil {
+		BeepFail()
+		if rollbackErr := RollbackProject(projectRoot); rollbackErr != nil {
+			return WrapError(rollbackErr, 148)
+		}
+		return WrapError(err, 147)
+	}
+
+	installed := GetInstalledKernel(projectRoot)
+	if installed == "" {
+		installed = version
+	}
+	if config.Kernel != "" {
+		config.Kernel = installed
+		if err := WriteProjectConfig(projectRoot, config); err != nil {
+			return WrapError(err, 144)
+		}
+	}
+
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "-m", "Upgrade PROS kernel to " + installed}); err != nil {
+		return WrapError(err, 149)
+	}
+
+	BeepSuccess()
+	return Success("kernel-upgraded", installed)
+}
+
+// RollbackProject discards all changes in the working tree, including untracked files
+func RollbackProject(projectRoot string) error {
+	return RunCommandsGetError(projectRoot,
+		[]string{"git", "reset", "--hard"},
+		[]string{"git", "clean", "-fd"})
+}
@@ -0,0 +1,140 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+const testProjectPros = `{
+	"py/object": "pros.conductor.project.Project",
+	"py/state": {
+		"project_name": "7984-TEST",
+		"target": "v5",
+		"templates": {
+			"kernel": {
+				"py/object": "pros.conductor.templates.local_template.LocalTemplate",
+				"py/state": {"name": "kernel", "version": "3.8.0", "target": "v5"}
+			},
+			"okapilib": {
+				"py/object": "pros.conductor.templates.local_template.LocalTemplate",
+				"py/state": {"name": "okapilib", "version": "4.8.0", "target": "v5"}
+			}
+		},
+		"upload_options": {}
+	}
+}`
+
+func TestGetInstalledTemplates(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	assert.Nil(t, GetInstalledTemplates(wd))
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+	assert.Equal(t, map[string]string{"kernel": "3.8.0", "okapilib": "4.8.0"}, GetInstalledTemplates(wd))
+	assert.Equal(t, "3.8.0", GetInstalledKernel(wd))
+}
+
+func TestGetKernelWarning(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+	assert.Equal(t, "", GetKernelWarning(wd))
+
+	WriteProjectConfig(wd, &ProjectConfig{Kernel: "3.8.0"})
+	assert.Equal(t, "", GetKernelWarning(wd))
+
+	WriteProjectConfig(wd, &ProjectConfig{Kernel: "3.7.3"})
+	assert.Contains(t, GetKernelWarning(wd), "3.7.3")
+	assert.Equal(t, 142, GetErrorCode(KernelCommand(wd, "check", "", false)))
+}
+
+func TestKernelCommandPin(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+
+	assert.Equal(t, 203, GetErrorCode(KernelCommand(wd, "pin", "latest", false)))
+	assert.Nil(t, KernelCommand(wd, "pin", "3.8.0", false))
+	assert.Equal(t, "3.8.0", ReadProjectConfig(wd).Kernel)
+	assert.Nil(t, KernelCommand(wd, "check", "", false))
+}
+
+func TestKernelCommandUpgrade(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+
+	MockCommandsQueue = []CommandSpec{
+		// uncommitted changes
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", " M src/main.cpp\n", "", 0},
+		// failed to build, rolled back
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"pros conductor apply kernel@3.8.1 --force-apply", "", "", 0},
+		{"make -j", "", "", 2},
+		{"git reset --hard", "", "", 0},
+		{"git clean -fd", "", "", 0},
+		// failed to apply, rolled back
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"pros conductor apply kernel@3.8.1 --force-apply", "", "ERROR - not found", 1},
+		{"git reset --hard", "", "", 0},
+		{"git clean -fd", "", "", 0},
+		// failed to apply, and failed to roll back
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"pros conductor apply kernel@3.8.1 --force-apply", "", "ERROR - not found", 1},
+		{"git reset --hard", "", "", 128},
+		// success
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"pros conductor apply kernel@latest --force-apply --no-download", "", "", 0},
+		{"make -j", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Upgrade PROS kernel to 3.8.0", "", "", 0},
+	}
+
+	assert.Equal(t, 145, GetErrorCode(KernelCommand(wd, "upgrade", "3.8.1", false)))
+	assert.Equal(t, 147, GetErrorCode(KernelCommand(wd, "upgrade", "3.8.1", false)))
+	assert.Equal(t, 146, GetErrorCode(KernelCommand(wd, "upgrade", "3.8.1", false)))
+	assert.Equal(t, 193, GetErrorCode(KernelCommand(wd, "upgrade", "3.8.1", false)))
+	assert.Nil(t, KernelCommand(wd, "upgrade", "", true))
+	assert.Empty(t, MockCommandsQueue)
+}
+
+func TestKernelCommandList(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+
+	MockCommandsQueue = []CommandSpec{
+		{"pros conductor query-depots", "kernel-depot: https://pros.cs.purdue.edu/v5/_static/releases/kernel-depot.json", "", 0},
+		{"pros conductor query-templates kernel --target v5", "kernel 3.8.0", "", 0},
+		{"pros conductor query-depots", "", "", 0},
+		{"pros conductor query-templates kernel --target v5 --offline-only", "kernel 3.8.0", "", 0},
+		{"pros conductor query-depots", "", "Error: not a PROS project", 1},
+	}
+
+	assert.Nil(t, KernelCommand(wd, "list", "", false))
+	assert.Nil(t, KernelCommand(wd, "list", "", true))
+	assert.Equal(t, 143, GetErrorCode(KernelCommand(wd, "list", "", false)))
+	assert.Empty(t, MockCommandsQueue)
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// Transliterations are the ASCII replacements of the lowercase accented letters in labels
+var Transliterations = map[rune]string{
+	'à': "a", 'á': "a", 'â': "a", 'ã': "a", 'ä': "a", 'å': "a", 'ā': "a", 'ă': "a", 'ą': "a",
+	'æ': "ae", 'ç': "c", 'ć': "c", 'č': "c", 'ď': "d", 'đ': "d",
+	'è': "e", 'é': "e", 'ê': "e", 'ë': "e", 'ē': "e", 'ę': "e", 'ě': "e", 'ğ': "g",
+	'ì': "i", 'í': "i", 'î': "i", 'ï': "i", 'ī': "i", 'ı': "i", 'ł': "l",
+	'ñ': "n", 'ń': "n", 'ň': "n", 'ò': "o", 'ó': "o", 'ô': "o", 'õ': "o", 'ö': "o", 'ø': "o", 'ō': "o", 'ő': "o",
+	'œ': "oe", 'ř': "r", 'ś': "s", 'š': "s", 'ş': "s", 'ß': "ss", 'ť': "t", 'þ': "th",
+	'ù': "u", 'ú': "u", 'û': "u", 'ü': "u", 'ū': "u", 'ů': "u", 'ű': "u", 'ý': "y", 'ÿ': "y", 'ź': "z", 'ż': "z", 'ž': "z",
+}
+
+// NormalizeLabel returns the label in capital letters with the accents removed, and spaces and underscores
+// replaced by hyphens. The result is not necessarily a valid label.
+// No side effect
+func NormalizeLabel(input string) string {
+	var sb strings.Builder
+	for _, c := range strings.TrimSpace(input) {
+		c = unicode.ToLower(c)
+		if replacement, ok := Transliterations[c]; ok {
+			sb.WriteString(replacement)
+		} else if c == ' ' || c == '_' || c == '-' {
+			sb.WriteRune('-')
+		} else {
+			sb.WriteRune(c)
+		}
+	}
+
+	label := strings.ToUpper(sb.String())
+	for strings.Contains(label, "--") {
+		label = strings.ReplaceAll(label, "--", "-")
+	}
+	return strings.Trim(label, "-")
+}
+
+// GetEditDistance returns the Levenshtein distance between the strings.
+// No side effect
+func GetEditDistance(a string, b string) int {
+	ra, rb := []rune(a), []rune(b)
+	prev := make([]int, len(rb)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(ra); i++ {
+		curr := make([]int, len(rb)+1)
+		curr[0] = i
+		for j := 1; j <= len(rb); j++ {
+			cost := 1
+			if ra[i-1] == rb[j-1] {
+				cost = 0
+			}
+			curr[j] = curr[j-1] + 1
+			if prev[j]+1 < curr[j] {
+				curr[j] = prev[j] + 1
+			}
+			if prev[j-1]+cost < curr[j] {
+				curr[j] = prev[j-1] + cost
+			}
+		}
+		prev = curr
+	}
+	return prev[len(rb)]
+}
+
+// FindSimilarLabels returns the closest candidates to the label, sorted. A candidate is similar if it is at
+// most a third of the label length of edits away, or contains the label. The hyphens are ignored.
+// No side effect
+func FindSimilarLabels(label string, candidates []string) []string {
+	label = strings.ReplaceAll(label, "-", "")
+	maxDistance := len(label) / 3
+	if maxDistance < 1 {
+		maxDistance = 1
+	}
+
+	best := -1
+	rtn := []string{}
+	for _, candidate := range candidates {
+		compact := strings.ReplaceAll(candidate, "-", "")
+		distance := GetEditDistance(label, compact)
+		if distance > maxDistance {
+			if label == "" || !strings.Contains(compact, label) {
+				continue
+			}
+			// Any containing candidate is farther than the close ones
+			distance = maxDistance + 1 + len(compact) - len(label)
+		}
+
+		if best == -1 || distance < best {
+			best = distance
+			rtn = []string{candidate}
+		} else if distance == best {
+			rtn = append(rtn, candidate)
+		}
+	}
+
+	sort.Strings(rtn)
+	return rtn
+}
+
+// ConfirmSimilarLabel returns true if the user accepts the similar label, or false if the input is not a
+// terminal
+var ConfirmSimilarLabel = func(similar string) bool {
+	if !IsTerminal(os.Stdin) {
+		return false
+	}
+
+	fmt.Fprint(Console, InfoText(T("label-confirm", similar))+" [y/N]: ")
+	answer, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	answer = strings.ToLower(strings.TrimSpace(answer))
+	return answer == "y" || answer == "yes"
+}
+
+// ResolveRemoteLabel returns the label if the repository exists on the server, or the only closest label of
+// the repositories on the server if the user accepts it or yes is true. The labels are fetched again if the
+// label is not in the cache. The label is returned as it is if the server cannot be reached.
+func ResolveRemoteLabel(label string, yes bool) (string, error) {
+	remote := GetCachedRemoteLabels()
+	if !Contains(remote, label) {
+		// The repository may be created after the labels are cached
+		labels, err := FetchRemoteLabels()
+		if err != nil {
+			return label, nil
+		}
+		remote = labels
+	}
+	if len(remote) == 0 || Contains(remote, label) {
+		return label, nil
+	}
+
+	similar := FindSimilarLabels(label, remote)
+	if len(similar) == 0 {
+		return "", NewError(188, label)
+	} else if len(similar) > 1 {
+		return "", NewError(189, label, strings.Join(similar, ", "))
+	}
+
+	fmt.Fprintln(Console, WarningText(T("label-similar", label, similar[0])))
+	if !yes && !ConfirmSimilarLabel(similar[0]) {
+		return "", NewError(192, similar[0])
+	}
+	return similar[0], nil
+}
+
+// PrintResolvedLabel prints the repo slug, the repository name and the local directory of the label
+func PrintResolvedLabel(label string, workspaceDir string) {
+	projectRootName := Secret["repo-slug-prefix"] + label
+	repoSlug := strings.ToLower(projectRootName)
+	repoName := Secret["repo-name-prefix"] + label
+	projectRoot := filepath.Join(workspaceDir, projectRootName)
+
+	ReportData("label", label)
+	ReportData("repo-slug", repoSlug)
+	ReportData("repo-name", repoName)
+	ReportData("directory", projectRoot)
+
+	fmt.Fprintln(Console, InfoText(T("resolved-label"))+label)
+	fmt.Fprintln(Console, InfoText(T("resolved-repository"))+repoName+" ("+Secret["workspace"]+"/"+repoSlug+")")
+	fmt.Fprintln(Console, InfoText(T("resolved-directory"))+projectRoot)
+}
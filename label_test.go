@@ -0,0 +1,105 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNormalizeLabel(t *testing.T) {
+	assert.Equal(t, "WORLDS-2025", NormalizeLabel("worlds-2025"))
+	assert.Equal(t, "WORLDS-2025", NormalizeLabel(" worlds 2025 "))
+	assert.Equal(t, "SKILLS-AUTON", NormalizeLabel("skills__auton"))
+	assert.Equal(t, "MONTREAL-CAFE", NormalizeLabel("Montréal Café"))
+	assert.Equal(t, "STRASSE-AEON", NormalizeLabel("Straße_Æon"))
+	assert.Equal(t, "A", NormalizeLabel("-a-"))
+	assert.Equal(t, "A.B", NormalizeLabel("a.b"))
+	assert.False(t, IsValidLabel(NormalizeLabel("a.b")))
+}
+
+func TestGetEditDistance(t *testing.T) {
+	assert.Equal(t, 0, GetEditDistance("WORLDS", "WORLDS"))
+	assert.Equal(t, 1, GetEditDistance("WORLD", "WORLDS"))
+	assert.Equal(t, 2, GetEditDistance("WROLDS", "WORLDS"))
+	assert.Equal(t, 3, GetEditDistance("", "ABC"))
+	assert.Equal(t, 3, GetEditDistance("KITTEN", "SITTING"))
+}
+
+func TestFindSimilarLabels(t *testing.T) {
+	candidates := []string{"WORLDS-2025", "WORLDS-2024", "SKILLS", "SKILLS-AUTON", "A", "B"}
+	assert.Equal(t, []string{"WORLDS-2025"}, FindSimilarLabels("WORLDS2025", candidates))
+	assert.Equal(t, []string{"WORLDS-2024", "WORLDS-2025"}, FindSimilarLabels("WORLDS-2026", candidates))
+	assert.Equal(t, []string{"SKILLS"}, FindSimilarLabels("SKILL", candidates))
+	assert.Equal(t, []string{"SKILLS-AUTON"}, FindSimilarLabels("AUTON", candidates))
+	assert.Equal(t, []string{"A", "B"}, FindSimilarLabels("C", candidates))
+	assert.Equal(t, []string{}, FindSimilarLabels("PUSHBOT", candidates))
+}
+
+// newLabelServer returns a server which lists the repositories with the slugs, and counts the requests
+func newLabelServer(t *testing.T, requests *int, slugs ...string) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		*requests++
+		values := []map[string]string{}
+		for _, slug := range slugs {
+			values = append(values, map[string]string{"slug": slug})
+		}
+		json.NewEncoder(w).Encode(map[string]any{"values": values})
+	}))
+
+	original := BitbucketApiUrl
+	BitbucketApiUrl = server.URL
+	t.Cleanup(func() {
+		BitbucketApiUrl = original
+		server.Close()
+	})
+	return server
+}
+
+func TestResolveRemoteLabel(t *testing.T) {
+	defer ResetRemoteLabels()
+
+	// The server cannot be reached
+	server := newLabelServer(t, new(int))
+	server.Close()
+	ResetRemoteLabels()
+	label, err := ResolveRemoteLabel("WORLDS", false)
+	assert.Nil(t, err)
+	assert.Equal(t, "WORLDS", label)
+
+	requests := 0
+	newLabelServer(t, &requests, "7984-worlds-2024", "7984-worlds-2025", "7984-skills")
+	label, _ = ResolveRemoteLabel("SKILLS", false)
+	assert.Equal(t, "SKILLS", label)
+	assert.Equal(t, 1, requests)
+	label, _ = ResolveRemoteLabel("SKILLS", false)
+	assert.Equal(t, 1, requests)
+
+	original := ConfirmSimilarLabel
+	defer func() { ConfirmSimilarLabel = original }()
+	ConfirmSimilarLabel = func(similar string) bool { return false }
+
+	// The similar label is used only if it is accepted
+	label, _ = ResolveRemoteLabel("WORLDS2025", true)
+	assert.Equal(t, "WORLDS-2025", label)
+	_, err = ResolveRemoteLabel("WORLDS2025", false)
+	assert.Equal(t, 192, GetErrorCode(err))
+	ConfirmSimilarLabel = func(similar string) bool { return similar == "WORLDS-2025" }
+	label, _ = ResolveRemoteLabel("WORLDS2025", false)
+	assert.Equal(t, "WORLDS-2025", label)
+
+	_, err = ResolveRemoteLabel("WORLDS-2026", true)
+	assert.Equal(t, 189, GetErrorCode(err))
+	_, err = ResolveRemoteLabel("PUSHBOT", true)
+	assert.Equal(t, 188, GetErrorCode(err))
+
+	// A repository created after the labels are cached is found
+	requests = 0
+	newLabelServer(t, &requests, "7984-skills", "7984-pushbot")
+	label, _ = ResolveRemoteLabel("PUSHBOT", false)
+	assert.Equal(t, "PUSHBOT", label)
+	assert.Equal(t, 1, requests)
+	assert.Contains(t, GetCachedRemoteLabels(), "PUSHBOT")
+}
@@ -12,14 +12,17 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"os/signal"
 	"os/user"
 	"path/filepath"
 	"regexp"
 	"runtime"
 	"strconv"
 	"strings"
+	"sync"
+	"syscall"
+	"time"
 
-	"github.com/google/shlex"
 	cp "github.com/otiai10/copy"
 )
 
@@ -87,12 +90,12 @@ func SetupSecret() bool {
 
 	secretFromFile := ReadJson(SecretFilePath)
 	if secretFromFile == nil {
-		return Fail(126)
+		return ReportError(NewError(126))
 	}
 
 	if UpdateFileSecret(Secret, secretFromFile) {
 		WriteJson(SecretFilePath, secretFromFile)
-		fmt.Println(Yellow("Secret file updated."))
+		fmt.Fprintln(Console, InfoText(T("secret-updated")))
 	}
 	Secret = secretFromFile
 
@@ -125,10 +128,39 @@ func UpdateFileSecret(secret map[string]string, fileSecret map[string]string) bo
 }
 
 // RunCommand runs a command and returns the exit code.
+// The command and the tail of its output are logged if the session log is started. The output of
+// interactive commands is not captured.
 func RunCommand(cmd *exec.Cmd) int {
-	RunningCommands.PushBack(cmd)
+	var stdout, stderr *TailBuffer
+	if SessionLogPath != "" && cmd.Stdin == nil {
+		stdout = &TailBuffer{Max: MaxLoggedOutput}
+		stderr = &TailBuffer{Max: MaxLoggedOutput}
+		cmd.Stdout = CaptureOutput(cmd.Stdout, stdout)
+		cmd.Stderr = CaptureOutput(cmd.Stderr, stderr)
+	}
+
+	start := time.Now()
+	code := runCommand(cmd)
+	LogCommand(cmd, start, code, stdout, stderr)
+
+	return code
+}
+
+func runCommand(cmd *exec.Cmd) int {
+	// The command is started with the lock held, so that it is not killed before its process is known
+	RunningCommandsLock.Lock()
+	err := cmd.Start()
+	if err == nil {
+		RunningCommands.PushBack(cmd)
+	}
+	RunningCommandsLock.Unlock()
+	if err != nil {
+		return -1
+	}
 
 	defer func() {
+		RunningCommandsLock.Lock()
+		defer RunningCommandsLock.Unlock()
 		for e := RunningCommands.Front(); e != nil; e = e.Next() {
 			if e.Value == cmd {
 				RunningCommands.Remove(e)
@@ -137,8 +169,7 @@ func RunCommand(cmd *exec.Cmd) int {
 		}
 	}()
 
-	err := cmd.Run()
-	if err != nil {
+	if err := cmd.Wait(); err != nil {
 		if exit_err, ok := err.(*exec.ExitError); ok {
 			return exit_err.ExitCode()
 		} else {
@@ -148,6 +179,16 @@ func RunCommand(cmd *exec.Cmd) int {
 	return 0
 }
 
+// KillRunningCommands kills the commands which are still running, so that they do not outlive the CLI
+func KillRunningCommands() {
+	RunningCommandsLock.Lock()
+	defer RunningCommandsLock.Unlock()
+
+	for e := RunningCommands.Front(); e != nil; e = e.Next() {
+		e.Value.(*exec.Cmd).Process.Kill()
+	}
+}
+
 // RunCommand runs a command and returns the output, error and exit code.
 // The output is printed to stdout and stderr
 // Color code might not work on Windows
@@ -158,13 +199,12 @@ func RunCommandPrintOut(workingDir string, name string, arg ...string) (string,
 	cmd.Dir = workingDir
 
 	var stdoutBuf, stderrBuf bytes.Buffer
-	cmd.Stdout = io.MultiWriter(os.Stdout, &stdoutBuf)
-	cmd.Stderr = io.MultiWriter(os.Stderr, &stderrBuf)
+	stdout, stderr := GetCommandOutput(workingDir)
+	cmd.Stdout = io.MultiWriter(stdout, &stdoutBuf)
+	cmd.Stderr = io.MultiWriter(stderr, &stderrBuf)
 
 	exitCode := RunCommand(cmd)
-	stdout := stdoutBuf.String()
-	stderr := stderrBuf.String()
-	return stdout, stderr, exitCode
+	return stdoutBuf.String(), stderrBuf.String(), exitCode
 }
 
 // RunCommandGetOutput runs a command and returns the output, error and exit code
@@ -190,8 +230,7 @@ func RunCommandGetStatus(workingDir string, name string, arg ...string) int {
 	cmd := ExecCommand(name, arg...)
 	cmd.Dir = workingDir
 
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
+	cmd.Stdout, cmd.Stderr = GetCommandOutput(workingDir)
 
 	// Some commands like Git will mess up the terminal color on Windows
 	defer FixConsoleColor()
@@ -199,6 +238,23 @@ func RunCommandGetStatus(workingDir string, name string, arg ...string) int {
 	return RunCommand(cmd)
 }
 
+// RunCommandGetError runs a command and returns an error if the exit code is not 0
+// The output is printed
+func RunCommandGetError(workingDir string, name string, arg ...string) error {
+	return GetExitError(RunCommandGetStatus(workingDir, name, arg...), name, arg...)
+}
+
+// RunCommandsGetError runs the commands one by one and returns the error of the first failed command
+// The output is printed
+func RunCommandsGetError(workingDir string, commands ...[]string) error {
+	for _, command := range commands {
+		if err := RunCommandGetError(workingDir, command[0], command[1:]...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // IsCommandSuccess runs a command and returns true if the exit code is 0
 // The output is not printed
 func IsCommandSuccess(workingDir string, name string, arg ...string) bool {
@@ -211,47 +267,59 @@ func SetupEnvironment() bool {
 
 	usr, err := user.Current()
 	if err != nil {
-		return Fail(127) // critical failure
+		return ReportError(NewError(127)) // critical failure
 	}
 
 	if runtime.GOOS == "linux" {
-		ids, err := usr.GroupIds()
+		ok, err := IsInDialoutGroup(usr)
 		if err != nil {
-			return Fail(127) // critical failure
+			return ReportError(NewError(127)) // critical failure
 		}
-
-		dialoutGroup, err := user.LookupGroup("dialout")
-		// if the group exists and the user is not in it
-		if err == nil && !Contains(ids, dialoutGroup.Gid) {
-			success = Fail(133)
+		if !ok {
+			success = ReportError(NewError(133))
 		}
 	}
 
+	// Use PROS-CLI and the toolchain installed by the VS Code extension if they are not in the environment
+	SetupProsInstall()
+
 	if _, err := exec.LookPath("git"); err != nil {
-		success = Fail(100)
+		success = ReportError(NewError(100))
 	}
 	if _, err := exec.LookPath("pros"); err != nil {
-		success = Fail(101)
+		success = ReportError(NewError(101))
 	}
 
 	if _, ok := os.LookupEnv("PROS_TOOLCHAIN"); !ok {
-		success = Fail(132)
+		success = ReportError(NewError(132))
 	}
 
 	AdminDir = filepath.Join(usr.HomeDir, ".cmapi-cli")
 	if os.MkdirAll(AdminDir, os.ModePerm) != nil {
-		success = Fail(128)
+		success = ReportError(NewError(128))
 	}
 
 	wd, err := os.Getwd()
 	if err != nil {
-		success = Fail(129)
+		success = ReportError(NewError(129))
 	}
 	WorkingDir = wd
 
 	return success // return true if and only if all checks passed
 }
 
+// IsInDialoutGroup returns true if the user is in the 'dialout' group, or if the group does not exist.
+// No side effect
+func IsInDialoutGroup(usr *user.User) (bool, error) {
+	ids, err := usr.GroupIds()
+	if err != nil {
+		return false, err
+	}
+
+	dialoutGroup, err := user.LookupGroup("dialout")
+	return err != nil || Contains(ids, dialoutGroup.Gid), nil
+}
+
 // Returns true if the given path is a Git repository.
 func IsGitRepo(projectRoot string) bool {
 	return IsCommandSuccess(projectRoot, "git", "rev-parse")
@@ -260,7 +328,7 @@ func IsGitRepo(projectRoot string) bool {
 // Returns true if the given path is a PROS project. However, it does not check if the project is set up probably.
 // No side effect
 func IsProsProject(projectRoot string) bool {
-	_, err := os.Stat(filepath.Join(projectRoot, "project.pros"))
+	_, err := os.Stat(GetProsProjectPath(projectRoot))
 	return !os.IsNotExist(err)
 }
 
@@ -270,302 +338,495 @@ func GetRepoUrl(repoSlug string) string {
 		Secret["workspace"] + "/" + repoSlug + ".git"
 }
 
-func LinkLocalRepoToServerCommand(projectRoot string, repoSlug string) bool {
+func LinkLocalRepoToServerCommand(projectRoot string, repoSlug string) error {
 	if !IsGitRepo(projectRoot) {
-		return Fail(102)
+		return NewError(102)
 	}
 
 	url := GetRepoUrl(repoSlug)
 	setUrl, _, code := RunCommandGetOutput(projectRoot, "git", "remote", "get-url", "origin")
 
-	var result bool
+	var err error
 	if strings.TrimSpace(setUrl) == url {
-		result = true
+		err = nil
 	} else if code == 0 {
-		result = IsCommandSuccess(projectRoot, "git", "remote", "set-url", "origin", url)
+		err = RunCommandGetError(projectRoot, "git", "remote", "set-url", "origin", url)
 	} else {
-		result = IsCommandSuccess(projectRoot, "git", "remote", "add", "origin", url)
+		err = RunCommandGetError(projectRoot, "git", "remote", "add", "origin", url)
 	}
 
-	if !result {
-		return Fail(103)
+	if err != nil {
+		return WrapError(err, 103)
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "config", "user.name", Secret["computer-name"]) ||
-		!IsCommandSuccess(projectRoot, "git", "config", "user.email", Secret["email"]) ||
-		!IsCommandSuccess(projectRoot, "git", "config", "commit.gpgsign", "false") {
-		return Fail(104)
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "config", "user.name", Secret["computer-name"]},
+		[]string{"git", "config", "user.email", Secret["email"]},
+		[]string{"git", "config", "commit.gpgsign", "false"}); err != nil {
+		return WrapError(err, 104)
 	}
 
-	return Success("Linked '%s' -> 'https://bitbucket.org/%s'.", projectRoot, Secret["workspace"]+"/"+repoSlug)
+	return Success("linked", projectRoot, Secret["workspace"]+"/"+repoSlug)
 }
 
 // BackupCommand backs up the project to the server
-func BackupCommand(projectRoot string) bool {
+func BackupCommand(projectRoot string) error {
 	if !IsGitRepo(projectRoot) {
-		return Fail(102)
+		return NewError(102)
+	}
+
+	if err := RunPreHooks(projectRoot, "pre-backup", "backup"); err != nil {
+		return err
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "add", "-A") ||
-		!IsCommandSuccess(projectRoot, "git", "commit", "-m", "Backup") {
-		return Fail(105)
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "-m", "Backup"}); err != nil {
+		return WrapError(err, 105)
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "push", "-u", "origin", "master") {
-		return Fail(106)
+	if err := RunCommandGetError(projectRoot, "git", "push", "-u", "origin", "master"); err != nil {
+		return WrapError(err, 106)
 	}
 
-	return Success("All changes have been backed up to the server.")
+	return Success("backed-up")
 }
 
-func BuildCommand(projectRoot string) bool {
+// CommitChangedFiles commits the files changed since the last commit, e.g. by the pre-backup hook, if any
+func CommitChangedFiles(projectRoot string) error {
+	if status, _, _ := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain"); status == "" {
+		return nil
+	}
+
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "-m", "Backup"}); err != nil {
+		return WrapError(err, 105)
+	}
+	return nil
+}
+
+func BuildCommand(projectRoot string) error {
 	if !IsProsProject(projectRoot) {
-		return Fail(134)
+		return NewError(134)
+	}
+
+	WarnKernelMismatch(projectRoot)
+
+	if err := RunPreHooks(projectRoot, "pre-build", "b"); err != nil {
+		return err
 	}
 
-	fmt.Println(Yellow("------------------ Make Project ------------------"))
+	entry := NewHistoryEntry(projectRoot, "b", 0)
 
-	var result bool
-	result = IsCommandSuccess(projectRoot, "make", "-j")
+	fmt.Fprintln(Console, HeaderText("------------------ Make Project ------------------"))
 
-	if !result {
+	start := time.Now()
+	entry.MakeExitCode = RunCommandGetStatus(projectRoot, "make", "-j")
+	entry.MakeSeconds = time.Since(start).Seconds()
+	entry.Success = entry.MakeExitCode == 0
+	RecordHistory(entry)
+
+	RunPostHooks(projectRoot, "post-build", "b", entry.Success)
+
+	if !entry.Success {
 		BeepFail()
-		return Fail(107)
+		return WrapError(GetExitError(entry.MakeExitCode, "make"), 107)
 	}
 
 	BeepSuccess()
-	return true
+	return nil
 }
 
-func CompileCommand(projectRoot string, all bool, slot int) bool {
+func CompileCommand(projectRoot string, all bool, slot int) error {
 	if !IsProsProject(projectRoot) {
-		return Fail(134)
+		return NewError(134)
 	}
 
-	fmt.Println(Yellow("------------------ Make Project ------------------"))
+	command := "normal"
+	if all {
+		command = "all"
+	}
+	WarnKernelMismatch(projectRoot)
 
-	var result bool
+	slotEnv := "CMAPI_CLI_SLOT=" + strconv.Itoa(slot)
+	if err := RunPreHooks(projectRoot, "pre-build", command, slotEnv); err != nil {
+		return err
+	}
+
+	entry := NewHistoryEntry(projectRoot, command, slot)
+
+	fmt.Fprintln(Console, HeaderText("------------------ Make Project ------------------"))
+
+	start := time.Now()
 	if all {
-		result = IsCommandSuccess(projectRoot, "make", "all", "-j")
+		entry.MakeExitCode = RunCommandGetStatus(projectRoot, "make", "all", "-j")
 	} else {
-		result = IsCommandSuccess(projectRoot, "make", "-j")
+		entry.MakeExitCode = RunCommandGetStatus(projectRoot, "make", "-j")
 	}
+	entry.MakeSeconds = time.Since(start).Seconds()
+
+	RunPostHooks(projectRoot, "post-build", command, entry.MakeExitCode == 0, slotEnv)
 
-	if !result {
+	if entry.MakeExitCode != 0 {
+		RecordHistory(entry)
 		BeepFail()
-		return Fail(107)
+		return WrapError(GetExitError(entry.MakeExitCode, "make"), 107)
 	}
 
+	if err := RunPreHooks(projectRoot, "pre-upload", command, slotEnv); err != nil {
+		RecordHistory(entry)
+		return err
+	}
+
+	start = time.Now()
+	entry.UploadExitCode, entry.Retries = UploadProgram(projectRoot, slot)
+	entry.UploadSeconds = time.Since(start).Seconds()
+	entry.Success = entry.UploadExitCode == 0
+	RecordHistory(entry)
+
+	RunPostHooks(projectRoot, "post-upload", command, entry.Success, slotEnv)
+
+	if entry.Success {
+		BeepSuccess()
+		return nil
+	}
+
+	BeepFail()
+	return WrapError(GetExitError(entry.UploadExitCode, "pros", "upload"), 108)
+}
+
+// UploadProgram waits for the V5 Brain and uploads the program to the given slot, retrying up to 5 times.
+// Extra arguments are passed to the upload command.
+// Returns the exit code of the last attempt and the number of retries.
+func UploadProgram(projectRoot string, slot int, extraArgs ...string) (int, int) {
 	for {
 		info, _, _ := RunCommandGetOutput(projectRoot, "pros", "lsusb", "--target", "v5")
 		if strings.Contains(info, " - ") {
 			break
 		}
-		fmt.Println(Yellow("V5 product not found, retrying..."))
+		fmt.Fprintln(Console, WarningText(T("upload-waiting")))
 	}
 
-	fmt.Println(Yellow("Starting to upload"))
+	fmt.Fprintln(Console, InfoText(T("upload-starting")))
 
+	args := append([]string{"upload", "--after", "screen", "--slot", strconv.Itoa(slot)}, extraArgs...)
+	code := 0
 	for i := 0; i < 6; i++ {
 		if i != 0 {
-			fmt.Printf(Yellow("Upload failed, retrying... (%d/5)\n"), i)
+			fmt.Fprintln(Console, WarningText(T("upload-retrying", i)))
 		}
-		if IsCommandSuccess(projectRoot, "pros", "upload", "--after", "screen", "--slot", strconv.Itoa(slot)) {
-			BeepSuccess()
-			return true
+		code = RunCommandGetStatus(projectRoot, "pros", args...)
+		if code == 0 {
+			return 0, i
 		}
 	}
 
-	BeepFail()
-	return Fail(108)
+	return code, 5
 }
 
-func InitProjectCommand(projectRoot string, kernel string, force bool, noPull bool) bool {
+func InitProjectCommand(projectRoot string, kernel string, force bool, noPull bool) error {
 	if IsProsProject(projectRoot) && !force {
-		return Fail(109)
+		return NewError(109)
 	}
 
-	if !InitGitRepo(projectRoot) {
-		return false
+	if err := InitGitRepo(projectRoot); err != nil {
+		return err
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "config", "commit.gpgsign", "false") {
-		return Fail(104)
+	if err := RunCommandGetError(projectRoot, "git", "config", "commit.gpgsign", "false"); err != nil {
+		return WrapError(err, 104)
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "add", "-A") ||
-		!IsCommandSuccess(projectRoot, "git", "commit", "--allow-empty", "-m", "Apply PROS kernel") {
-		return Fail(110)
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "--allow-empty", "-m", "Apply PROS kernel"}); err != nil {
+		return WrapError(err, 110)
 	}
 
 	result := InitProsProjectAndApplyKernel(projectRoot, kernel, noPull)
 
 	// No matter what, we reset the repository
 	// Apply the kernel to the project without overwriting any existing files.
-	if !IsCommandSuccess(projectRoot, "git", "reset", "--hard") {
-		return Fail(111)
+	if err := RunCommandGetError(projectRoot, "git", "reset", "--hard"); err != nil {
+		return WrapError(err, 111)
+	}
+
+	if result != nil {
+		return result
 	}
 
-	return result && Success("Initialized PROS project at '%s'.", projectRoot)
+	return Success("initialized", projectRoot)
 }
 
-func PullCommand(projectRoot string) bool {
+// StatusCommand shows the branch, the uncommitted changes and the kernel of the project
+func StatusCommand(projectRoot string) error {
+	ReportData("project", projectRoot)
+	fmt.Fprintln(Console, InfoText(T("status-project"))+projectRoot)
+
+	if IsGitRepo(projectRoot) {
+		branch, _, _ := RunCommandGetOutput(projectRoot, "git", "rev-parse", "--abbrev-ref", "HEAD")
+		changes, _, _ := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain")
+		count := 0
+		for _, line := range strings.Split(changes, "\n") {
+			if strings.TrimSpace(line) != "" {
+				count++
+			}
+		}
+		ReportData("branch", strings.TrimSpace(branch))
+		ReportData("uncommitted-changes", count)
+		fmt.Fprintln(Console, InfoText(T("status-branch"))+strings.TrimSpace(branch))
+		fmt.Fprintln(Console, InfoText(T("status-changes"))+strconv.Itoa(count))
+	} else {
+		fmt.Fprintln(Console, InfoText(T("status-branch"))+T("status-not-git"))
+	}
+
+	if !IsProsProject(projectRoot) {
+		return NewError(134)
+	}
+
+	project, err := ReadProsProject(projectRoot)
+	if err != nil {
+		return NewError(153, err)
+	}
+	ReportData("name", project.ProjectName)
+	ReportData("target", project.Target)
+	fmt.Fprintln(Console, InfoText(T("status-name"))+project.ProjectName)
+	fmt.Fprintln(Console, InfoText(T("status-target"))+project.Target)
+
+	installed := GetInstalledTemplates(projectRoot)
+	ReportData("templates", installed)
+	for _, name := range GetSortedKeys(installed) {
+		fmt.Fprintln(Console, InfoText(T("status-template"))+name+"@"+installed[name])
+	}
+
+	if config := ReadProjectConfig(projectRoot); config != nil && config.Kernel != "" {
+		ReportData("pinned-kernel", config.Kernel)
+		fmt.Fprintln(Console, InfoText(T("status-kernel-pinned"))+config.Kernel)
+	}
+	WarnKernelMismatch(projectRoot)
+
+	return nil
+}
+
+func PullCommand(projectRoot string) error {
 	if !IsGitRepo(projectRoot) {
-		return Fail(102)
+		return NewError(102)
 	}
 
-	if !IsCommandSuccess(projectRoot, "git", "pull") {
-		return Fail(112)
+	if err := RunCommandGetError(projectRoot, "git", "pull"); err != nil {
+		return WrapError(err, 112)
 	}
 
-	return Success("All changes have been pulled from the server.")
+	return Success("pulled")
 }
 
-func CloneRepositoryCommand(label string, workspaceDir string, kernel string, noPull bool) bool {
+func CloneRepositoryCommand(label string, workspaceDir string, kernel string, noPull bool) error {
+	PrintResolvedLabel(label, workspaceDir)
+
 	projectRootName := Secret["repo-slug-prefix"] + label
 	repoSlug := strings.ToLower(projectRootName)
 	projectRoot := filepath.Join(workspaceDir, projectRootName)
 
 	err := os.MkdirAll(projectRoot, os.ModePerm)
 	if err != nil {
-		return Fail(113, projectRoot)
+		return WrapError(err, 113, projectRoot)
 	}
 
 	url := GetRepoUrl(repoSlug)
 
-	if !IsCommandSuccess(workspaceDir, "git", "clone", url, projectRootName) {
-		return Fail(114)
+	if err := RunCommandGetError(workspaceDir, "git", "clone", url, projectRootName); err != nil {
+		return WrapError(err, 114)
 	}
 
-	if !InitProsProjectAndApplyKernel(projectRoot, kernel, noPull) {
-		return false
+	if err := InitProsProjectAndApplyKernel(projectRoot, kernel, noPull); err != nil {
+		return err
 	}
 
 	// No matter what, we reset the repository
 	// Apply the kernel to the project without overwriting any existing files.
-	if !IsCommandSuccess(projectRoot, "git", "reset", "--hard") {
-		return Fail(131)
+	if err := RunCommandGetError(projectRoot, "git", "reset", "--hard"); err != nil {
+		return WrapError(err, 131)
 	}
 
-	return Success("Cloned 'https://bitbucket.org/%s' -> '%s'.", Secret["workspace"]+"/"+repoSlug, projectRoot)
+	return Success("cloned", Secret["workspace"]+"/"+repoSlug, projectRoot)
 }
 
-func CreateRepositoryCommand(label string, workspaceDir string, kernel string, noPull bool, isLocal bool) bool {
-	templateRepoSlug := strings.ToLower(Secret["template-repo"])
+// UpdateTemplateRepo clones or pulls the template repository in the administrator directory unless noPull is
+// true, and returns its root
+func UpdateTemplateRepo(templateRepoSlug string, noPull bool) (string, error) {
 	templateRoot := filepath.Join(AdminDir, templateRepoSlug)
 
 	if !noPull {
 		if !IsGitRepo(templateRoot) {
-			if !IsCommandSuccess(AdminDir, "git", "clone", GetRepoUrl(templateRepoSlug), templateRepoSlug) {
-				return Fail(115)
+			if err := RunCommandGetError(AdminDir, "git", "clone", GetRepoUrl(templateRepoSlug), templateRepoSlug); err != nil {
+				return "", WrapError(err, 115)
 			}
 		} else {
-			if !LinkLocalRepoToServerCommand(templateRoot, templateRepoSlug) {
-				return Fail(116)
+			if err := LinkLocalRepoToServerCommand(templateRoot, templateRepoSlug); err != nil {
+				return "", WrapError(err, 116)
 			}
 
 			// Do not use PullCommand because the default branch may not be master
 			if _, _, code := RunCommandPrintOut(templateRoot, "git", "pull"); code != 0 {
-				return Fail(112)
+				return "", WrapError(GetExitError(code, "git", "pull"), 112)
 			}
 		}
 	}
 
 	if !IsGitRepo(templateRoot) {
-		return Fail(117, templateRoot)
+		return "", NewError(117, templateRoot)
+	}
+
+	return templateRoot, nil
+}
+
+func CreateRepositoryCommand(label string, workspaceDir string, templateName string, kernel string, noPull bool, isLocal bool) error {
+	templateRoot, err := PrepareTemplate(templateName, noPull)
+	if err != nil {
+		return err
+	}
+	if templateName == "" {
+		templateName = GetTemplateName(Secret["template-repo"])
 	}
+	PrintResolvedLabel(label, workspaceDir)
 
 	projectRootName := Secret["repo-slug-prefix"] + label
 	projectSlug := strings.ToLower(projectRootName)
 	projectRoot := filepath.Join(workspaceDir, projectRootName)
 
 	if IsGitRepo(projectRoot) {
-		return Fail(118)
+		return NewError(118)
 	}
 
-	err := os.MkdirAll(projectRoot, os.ModePerm)
+	variables, err := GetTemplateVariables(templateRoot, label, projectRootName)
 	if err != nil {
-		return Fail(113, projectRoot)
+		return err
+	}
+
+	err = os.MkdirAll(projectRoot, os.ModePerm)
+	if err != nil {
+		return WrapError(err, 113, projectRoot)
 	}
 
 	// copy everything from the template to the project
+	copied := []string{}
 	opt := cp.Options{
 		Skip: func(info os.FileInfo, src, dest string) (bool, error) {
-			return strings.HasSuffix(src, ".git"), nil
+			if strings.HasSuffix(src, ".git") || src == filepath.Join(templateRoot, TemplateManifestName) {
+				return true, nil
+			}
+			copied = append(copied, dest)
+			return false, nil
 		},
 	}
-	if cp.Copy(templateRoot, projectRoot, opt) != nil {
-		return Fail(119)
+	if err := cp.Copy(templateRoot, projectRoot, opt); err != nil {
+		return WrapError(err, 119)
+	}
+	if err := SubstituteTemplateFiles(copied, variables); err != nil {
+		return WrapError(err, 119)
 	}
 
-	if !InitProjectCommand(projectRoot, kernel, true, noPull) {
-		return false
+	// The template commit is the base of the three-way merge in template-sync
+	if err := RecordTemplate(projectRoot, templateRoot, templateName, variables); err != nil {
+		return WrapError(err, 119)
+	}
+
+	if err := InitProjectCommand(projectRoot, kernel, true, noPull); err != nil {
+		return err
 	}
 
 	if !isLocal {
 		status, err := CreateRemoteRepo(label)
 		if err != nil {
-			return Fail(120)
+			return WrapError(err, 120)
 		}
 
 		if status != "200 OK" {
-			return Fail(121, status)
+			return NewError(121, status)
 		}
+		ResetRemoteLabels()
 
-		if !LinkLocalRepoToServerCommand(projectRoot, projectSlug) {
-			return false
+		if err := LinkLocalRepoToServerCommand(projectRoot, projectSlug); err != nil {
+			return err
 		}
 
-		if !IsCommandSuccess(projectRoot, "git", "push", "-u", "origin", "master") {
-			return Fail(122)
+		if err := RunPreHooks(projectRoot, "pre-backup", "create"); err != nil {
+			return err
+		}
+		if err := CommitChangedFiles(projectRoot); err != nil {
+			return err
+		}
+
+		if err := RunCommandGetError(projectRoot, "git", "push", "-u", "origin", "master"); err != nil {
+			return WrapError(err, 122)
 		}
 	}
 
-	return Success("Created repository at '%s'.", projectRoot)
+	return Success("created", projectRoot)
 }
 
-func ListSecretsCommand() bool {
-	fmt.Println(Yellow("Listing secrets..."))
+func ListSecretsCommand() error {
+	fmt.Fprintln(Console, HeaderText(T("secrets-listing")))
 
+	secrets := map[string]string{}
 	for key, value := range Secret {
-		fmt.Println(Yellow(key+": ") + value)
+		secrets[key] = RedactSecret(key, value)
+		fmt.Fprintln(Console, InfoText(key+": ")+value)
 	}
+	ReportData("secrets", secrets)
 
-	return true
+	return nil
 }
 
-func SetSecretCommand(key string, value string) bool {
+func ShowSecretCommand(key string) error {
+	value, ok := Secret[key]
+	if !ok {
+		return NewError(130, key)
+	}
+
+	ReportData("secrets", map[string]string{key: RedactSecret(key, value)})
+	fmt.Fprintln(Console, InfoText(key+": ")+value)
+
+	return nil
+}
+
+func SetSecretCommand(key string, value string) error {
 	if _, ok := Secret[key]; !ok {
-		return Fail(130, key)
+		return NewError(130, key)
 	} else {
 		Secret[key] = value
 		WriteJson(SecretFilePath, Secret)
-		return true
+		if key == "language" {
+			Language = GetLanguage(value)
+		}
+		return nil
 	}
 }
 
 // InitGitRepo initializes a git repository in the project directory
-func InitGitRepo(projectRoot string) bool {
+func InitGitRepo(projectRoot string) error {
 	if !IsGitRepo(projectRoot) {
-		if !IsCommandSuccess(projectRoot, "git", "init", "--initial-branch=master") {
-			return Fail(123)
+		if err := RunCommandGetError(projectRoot, "git", "init", "--initial-branch=master"); err != nil {
+			return WrapError(err, 123)
 		}
 	}
 
-	return true
+	return nil
 }
 
 // InitProsProjectAndApplyKernel initializes a PROS project in the current directory
-func InitProsProjectAndApplyKernel(projectRoot string, kernel string, noPull bool) bool {
+// The templates recorded in the project config are applied after the kernel
+func InitProsProjectAndApplyKernel(projectRoot string, kernel string, noPull bool) error {
 	projectName := filepath.Base(projectRoot)
 
-	contents := `{
-	"py/object": "pros.conductor.project.Project",
-	"py/state": {"project_name": "` + projectName + `", "target": "v5", "templates": {}, "upload_options": {}}
-}`
-	err := os.WriteFile(filepath.Join(projectRoot, "project.pros"), []byte(contents), 0644)
-	if err != nil {
-		return Fail(124)
+	// Use the pinned kernel unless a version is specified
+	if config := ReadProjectConfig(projectRoot); kernel == "latest" && config != nil && config.Kernel != "" {
+		kernel = config.Kernel
+	}
+
+	if err := WriteProsProject(projectRoot, NewProsProject(projectName)); err != nil {
+		return WrapError(err, 124)
 	}
 
 	args := []string{"conductor", "install", "kernel@" + kernel, "-force-system"}
@@ -575,10 +836,65 @@ func InitProsProjectAndApplyKernel(projectRoot string, kernel string, noPull boo
 
 	out, errs, code := RunCommandPrintOut(projectRoot, "pros", args...)
 	if strings.Contains(out, "ERROR") || strings.Contains(errs, "ERROR") || code != 0 {
-		return Fail(125)
+		return WrapError(GetExitError(code, "pros", args...), 125)
 	}
 
-	return true
+	return ApplyProjectTemplates(projectRoot, noPull)
+}
+
+// NewBitbucketRequest returns a request to the Bitbucket API with the credentials in the secret.
+func NewBitbucketRequest(method string, url string, body io.Reader) (*http.Request, error) {
+	req, err := http.NewRequest(method, url, body)
+	if err != nil {
+		return nil, err
+	}
+
+	auth := base64.StdEncoding.EncodeToString([]byte(Secret["username"] + ":" + Secret["password"]))
+	req.Header.Add("Authorization", "Basic "+auth)
+	req.Header.Add("Content-Type", "application/json")
+
+	return req, nil
+}
+
+// ListRemoteRepos returns the slugs of all repositories in the workspace on the BitBucket server
+func ListRemoteRepos() ([]string, error) {
+	url := BitbucketApiUrl + "/repositories/" + Secret["workspace"] + "?pagelen=100&fields=next,values.slug"
+	client := &http.Client{}
+
+	rtn := []string{}
+	for url != "" {
+		req, err := NewBitbucketRequest("GET", url, nil)
+		if err != nil {
+			return nil, err
+		}
+
+		res, err := client.Do(req)
+		if err != nil {
+			return nil, err
+		}
+
+		var page struct {
+			Next   string `json:"next"`
+			Values []struct {
+				Slug string `json:"slug"`
+			} `json:"values"`
+		}
+		err = json.NewDecoder(res.Body).Decode(&page)
+		res.Body.Close()
+		if res.StatusCode != http.StatusOK {
+			return nil, errors.New("unexpected status " + res.Status)
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		for _, value := range page.Values {
+			rtn = append(rtn, value.Slug)
+		}
+		url = page.Next
+	}
+
+	return rtn, nil
 }
 
 // CreateRemoteRepo creates a remote repo on the BitBucket server, requires label with no spaces
@@ -586,7 +902,7 @@ func CreateRemoteRepo(label string) (string, error) {
 	projectRootName := Secret["repo-slug-prefix"] + label
 	repoSlug := strings.ToLower(projectRootName)
 
-	url := "https://api.bitbucket.org/2.0/repositories/" + Secret["workspace"] + "/" + repoSlug
+	url := BitbucketApiUrl + "/repositories/" + Secret["workspace"] + "/" + repoSlug
 	method := "POST"
 	payload := strings.NewReader(`{
         "scm": "git",
@@ -599,16 +915,11 @@ func CreateRemoteRepo(label string) (string, error) {
     }`)
 
 	client := &http.Client{}
-	req, err := http.NewRequest(method, url, payload)
-
+	req, err := NewBitbucketRequest(method, url, payload)
 	if err != nil {
 		return "", err
 	}
 
-	auth := base64.StdEncoding.EncodeToString([]byte(Secret["username"] + ":" + Secret["password"]))
-	req.Header.Add("Authorization", "Basic "+auth)
-	req.Header.Add("Content-Type", "application/json")
-
 	res, err := client.Do(req)
 	if err != nil {
 		return "", err
@@ -622,76 +933,52 @@ func CreateRemoteRepo(label string) (string, error) {
 	return res.Status, err
 }
 
-// HandleCommand handles the command line arguments
-// Returns true if the command was executed, false otherwise
+// HandleCommand handles the command line arguments, and reports the error if any
+// Returns true if the command succeeded, false otherwise
 func HandleCommand(command string, args []string) bool {
-	fs := flag.NewFlagSet("options", flag.ContinueOnError)
-	var workspaceDir string
-	var forceFlag bool
-	var kernelVer string
-	var localFlag bool
-	var noPullFlag bool
-	var slotFlag int
-	fs.StringVar(&workspaceDir, "d", Secret["workspace-dir"], "")
-	fs.StringVar(&workspaceDir, "directory", Secret["workspace-dir"], "")
-	fs.BoolVar(&forceFlag, "f", false, "")
-	fs.BoolVar(&forceFlag, "force", false, "")
-	fs.StringVar(&kernelVer, "k", "latest", "")
-	fs.StringVar(&kernelVer, "kernel", "latest", "")
-	fs.BoolVar(&localFlag, "l", false, "")
-	fs.BoolVar(&localFlag, "local", false, "")
-	fs.BoolVar(&noPullFlag, "np", false, "")
-	fs.BoolVar(&noPullFlag, "no-pull", false, "")
-	fs.IntVar(&slotFlag, "s", 1, "")
-	fs.IntVar(&slotFlag, "slot", 1, "")
-
-	fs.Parse(args)
-
-	if command == "all" {
-		CompileCommand(WorkingDir, true, slotFlag)
-	} else if command == "backup" {
-		BackupCommand(WorkingDir)
-	} else if command == "init" {
-		InitProjectCommand(WorkingDir, kernelVer, forceFlag, noPullFlag)
-	} else if command == "link" {
-		repoSlug := filepath.Base(WorkingDir)
-		if len(fs.Args()) > 0 {
-			repoSlug = fs.Arg(0)
-		}
-		LinkLocalRepoToServerCommand(WorkingDir, repoSlug)
-	} else if command == "b" {
-		BuildCommand(WorkingDir)
-	} else if command == "normal" {
-		CompileCommand(WorkingDir, false, slotFlag)
-	} else if command == "pull" {
-		PullCommand(WorkingDir)
-	} else if command == "clone" {
-		label := fs.Arg(0)
-		if !IsValidLabel(label) {
-			return Fail(200)
-		}
-		CloneRepositoryCommand(label, workspaceDir, kernelVer, noPullFlag)
-	} else if command == "create" {
-		label := fs.Arg(0)
-		if !IsValidLabel(label) {
-			return Fail(200)
-		}
-		CreateRepositoryCommand(label, workspaceDir, kernelVer, noPullFlag, localFlag)
-	} else if command == "help" {
-		fmt.Println(Yellow(usage))
-	} else if command == "secret" {
-		if len(fs.Args()) != 2 {
-			ListSecretsCommand()
-		} else {
-			SetSecretCommand(fs.Arg(0), fs.Arg(1))
+	// The output format may be changed by the option of the command
+	defer SetOutputFormat(OutputFormat)
+	defer SetColorMode(ColorMode)
+	defer func(verbose bool) { Verbose = verbose }(Verbose)
+
+	BeginCommandResult(command, args)
+	err := handleCommand(command, args)
+	if err != nil {
+		ReportError(err)
+	}
+	EndCommandResult(err == nil)
+
+	return err == nil
+}
+
+func handleCommand(command string, args []string) error {
+	cmd := FindCommand(command)
+	if cmd == nil {
+		if path := FindPlugin(command); path != "" {
+			return RunPluginCommand(path, WorkingDir, args)
 		}
-	} else {
-		return Fail(301, command)
+		return NewError(301, command)
 	}
 
-	// TODO
+	var opts CommandOptions
+	fs := NewCommandFlagSet(cmd, &opts)
+	if err := fs.Parse(args); err != nil {
+		return NewError(302, cmd.Name, err)
+	}
 
-	return true
+	if len(fs.Args()) < cmd.MinArgs || (cmd.MaxArgs >= 0 && len(fs.Args()) > cmd.MaxArgs) {
+		return NewError(303, cmd.Name, cmd.Name)
+	}
+
+	if !SetOutputFormat(opts.Output) {
+		return NewError(210, opts.Output)
+	}
+	if !SetColorMode(opts.Color) {
+		return NewError(211, opts.Color)
+	}
+	Verbose = opts.Verbose
+
+	return cmd.Run(opts, fs.Args())
 }
 
 func BeepFail() {
@@ -702,23 +989,13 @@ func BeepSuccess() {
 	Beep(1568, 100)
 }
 
-// Returns yellow text.
-// No side effect
-func Yellow(s string) string {
-	YELLOW := "\033[93m"
-	RESET := "\033[0m"
-	return YELLOW + s + RESET
-}
-
-// Print an error message in yellow
-func Fail(code int, args ...any) bool {
-	fmt.Printf(Yellow("Error %d: %s\n"), code, fmt.Sprintf(ErrorCode[code], args...))
-	return false
-}
-
-func Success(str string, args ...any) bool {
-	fmt.Printf(Yellow("%s\n"), fmt.Sprintf(str, args...))
-	return true
+// Success prints the message of the ID in the current language in the success style
+// Returns nil for convenience
+func Success(id string, args ...any) error {
+	message := T(id, args...)
+	recordMessage(message)
+	fmt.Fprintln(Console, SuccessText(message))
+	return nil
 }
 
 var ErrorCode = map[int]string{
@@ -757,66 +1034,92 @@ var ErrorCode = map[int]string{
 	132: "'PROS_TOOLCHAIN' environment variable is not defined.",
 	133: "User should be in the 'dialout' group.",
 	134: "Not a PROS project, use command 'init' to initialize it.",
+	135: "Failed to read the project config file.",
+	136: "No build variants are declared in the project config.",
+	137: "Build variant '%s' does not exist.",
+	138: "Invalid build variant name '%s', only letters, digits, underscores and hyphens are accepted.",
+	139: "Build variant '%s' has an invalid slot %d, the range is 1-8.",
+	140: "Failed to make build variant '%s'.",
+	141: "Failed to upload build variant '%s'.",
+	142: "The installed kernel %s does not match the pinned kernel %s.",
+	143: "Failed to query the kernel templates.",
+	144: "Failed to write the project config file.",
+	145: "There are uncommitted changes, use command 'backup' to commit them first.",
+	146: "Failed to apply kernel %s, the project has been rolled back.",
+	147: "Failed to make with the new kernel, the project has been rolled back.",
+	148: "Failed to make with the new kernel and failed to roll back the project.",
+	149: "Failed to commit the kernel upgrade.",
+	150: "Failed to apply template %s.",
+	151: "Failed to remove template '%s'.",
+	152: "Template '%s' is not recorded in the project config.",
+	153: "Failed to read project.pros: %s.",
+	154: "Failed to write the alias file.",
+	155: "Plugin '%s' exited with code %d.",
+	156: "Hook '%s' failed, the command is aborted.",
+	157: "Hook '%s' failed.",
+	158: "Failed to read the log file.",
+	159: "Failed to create the report.",
+	160: "Found %d problem(s) in the environment.",
+	161: "Failed to fix '%s'.",
+	162: "Failed to check the latest release.",
+	163: "No release asset '%s' for this platform.",
+	164: "Failed to download '%s'.",
+	165: "No checksum of '%s' in the release.",
+	166: "Checksum of '%s' does not match, the download is discarded.",
+	167: "Failed to replace the executable '%s'.",
+	168: "Failed to read the workspace directory '%s'.",
+	169: "%d of %d project(s) failed.",
+	170: "No directory or project with label '%s'.",
+	171: "Failed to change to '%s'.",
+	172: "No template commit is recorded in the project config, the project was not created from the template.",
+	173: "The project has uncommitted changes, commit or back up them first.",
+	174: "Failed to fetch the template repository into the project.",
+	175: "Failed to compare the template from %s to %s.",
+	176: "Failed to create branch '%s'.",
+	177: "Failed to apply the template changes on branch '%s'.",
+	178: "%d file(s) have conflicts on branch '%s', resolve them and commit.",
+	179: "Unknown template '%s', add it to the 'templates' secret, e.g. 'secret templates \"lemlib-template, ~/templates/skills\"'.",
+	180: "Invalid template manifest '%s'.",
+	181: "'%s' already exists.",
+	182: "Failed to rename the remote repository '%s'.",
+	183: "Failed to move '%s' to '%s'.",
+	184: "Failed to commit the rename.",
+	185: "'%s' has %d uncommitted change(s), %d stash(es) and %d commit(s) not pushed to the server, back up them first.",
+	186: "Failed to move the remote repository '%s' to project '%s'.",
+	187: "Failed to remove '%s'.",
+	188: "No repository with label '%s' or a similar label on the server.",
+	189: "Label '%s' is similar to more than one repository on the server: %s.",
+	190: "Failed to check '%s' for changes which are not pushed to the server.",
+	191: "The remote repository is renamed to '%s', but the project at '%s' is not updated.",
+	192: "The similar label '%s' is not accepted, use '--yes' to accept it without asking.",
+	193: "Failed to apply kernel %s and failed to roll back the project.",
 	200: "Invalid label, only capital letters, digits and hyphens are accepted.",
+	201: "Unknown action '%s'.",
+	202: "Invalid count '%s', a positive integer is expected.",
+	203: "Invalid kernel version '%s', a version like 3.8.0 is expected.",
+	204: "Invalid template '%s', a template like okapilib@4.8.0 is expected.",
+	205: "Use command 'kernel' to manage the kernel.",
+	206: "Invalid alias name '%s', only letters, digits, underscores and hyphens are accepted.",
+	207: "Alias name '%s' is already a command.",
+	208: "Alias '%s' refers to other aliases too deeply.",
+	209: "Alias '%s' does not exist.",
+	210: "Invalid output format '%s', text or json is expected.",
+	211: "Invalid color mode '%s', auto, always or never is expected.",
 	300: "Failed to parse command line.",
 	301: "Unknown command '%s'.",
+	302: "Invalid option for command '%s': %s.",
+	303: "Wrong number of arguments for command '%s', use 'help %s' to see its usage.",
 }
 
-const usage = `Usage: <command> [<args>, ...]
-
-Commands for project action:
-    all [--slot]
-        Remove all object files in the project's ./bin directory and compile 
-        all source files again. Attempt to connect the V5 Brain and upload the 
-        binary files.
-    backup
-        Commit and push all changes in the repository to the remote server.
-    init [--kernel <VERSION>] [--no-pull] [--force]
-        Initialize the Git repository and create the PROS project. Apply the 
-        kernel to the project without overwriting any existing files.
-    link [PROJECT_SLUG]
-        Link the current directory to a remote repository on Bitbucket. The 
-        project slug is the same as the project root directory name by default.
-    normal [--slot]
-        Compile source files normally in the current PROS project. Attempt to 
-        connect the V5 Brain and upload the binary files.
-    pull
-        Pull changes from the remote server to the local repository.
-
-Commands for repository management:
-    clone [--directory <PATH>] [--kernel <VERSION>] [--no-pull] <LABEL>
-        1. Clone a repository from the server to the local machine.
-        2. Initialize the PROS project.
-    create [--directory <PATH>] [--kernel <VERSION>] [--no-pull] [--local]
-        <LABEL>
-        1. Create a repository on the local machine. The label should be all
-        caps and contain no spaces.
-        2. Fork all contents from the template repository.
-        3. Initialize the PROS project.
-        4. Upload the repository to the server.
-    help
-        Display this help message.
-    secret [<KEY> <VALUE>]
-        List all secret keys and values or set a secret key and value.
-
-Options:
-    -d,  --directory <PATH>     The workspace directory. The parent directory
-                                of where all repositories located at.
-                                [default: DEFAULT SETTING]
-    -f,  --force                Force the action to run.
-    -k,  --kernel <VERSION>     The kernel version to use. [default: latest]
-    -l,  --local                Do not create a repository on the server.
-    -np, --no-pull              Do not pull template changes/kernel online.
-    -s,  --slot <SLOT>          Upload the binary to a specified program slot
-                                in the brain. [default: 1, range: 1-8]
-
-Version: 0.1.8`
+// Version is the version of this tool, set to the tag in the release workflow
+var Version = "0.1.8"
 
 // Returns true if the given label is valid
 // No side effect
 var (
-	ExecCommand  = exec.Command
-	IsValidLabel = regexp.MustCompile(`^[A-Z0-9\-]+$`).MatchString
+	ExecCommand     = exec.Command
+	IsValidLabel    = regexp.MustCompile(`^[A-Z0-9\-]+$`).MatchString
+	BitbucketApiUrl = "https://api.bitbucket.org/2.0"
 )
 
 var (
@@ -831,16 +1134,25 @@ var (
 		"workspace":        "vex7984",
 		"workspace-dir":    "",
 		"project":          "CURRENT",
+		"archive-project":  "ARCHIVE",
 		"template-repo":    "cmapi-build",
+		"templates":        "",
 		"repo-slug-prefix": "7984-",
 		"repo-name-prefix": "7984 - ",
+		"hook-pre-build":   "",
+		"hook-post-build":  "",
+		"hook-pre-upload":  "",
+		"hook-post-upload": "",
+		"hook-pre-backup":  "",
+		"language":         "",
 	}
 	RunningCommands = list.New()
+	// RunningCommandsLock guards RunningCommands, commands may run concurrently
+	RunningCommandsLock sync.Mutex
 
 	// Codes from github.com/gen2brain/beeep
 	// ErrUnsupported is returned when operating system is not supported.
 	ErrUnsupported = errors.New("beeep: unsupported operating system: " + runtime.GOOS)
-	ErrNoData      = errors.New("no data")
 )
 
 func main() {
@@ -848,61 +1160,97 @@ func main() {
 	BeepSuccess()
 
 	var force bool
+	var color string
+	var output string
 	fs := flag.NewFlagSet("Startup", flag.ContinueOnError)
 	fs.BoolVar(&force, "f", false, "")
 	fs.BoolVar(&force, "force", false, "")
+	fs.StringVar(&color, "c", "auto", "")
+	fs.StringVar(&color, "color", "auto", "")
+	fs.StringVar(&output, "o", "text", "")
+	fs.StringVar(&output, "output", "text", "")
+	fs.BoolVar(&Verbose, "v", false, "")
+	fs.BoolVar(&Verbose, "verbose", false, "")
 	fs.Parse(os.Args[1:])
 
-	if !SetupEnvironment() && !force {
-		return
+	if !SetOutputFormat(output) {
+		ReportError(NewError(210, output))
+		os.Exit(1)
+	}
+	if !SetColorMode(color) {
+		ReportError(NewError(211, color))
+		os.Exit(1)
 	}
 
-	if !SetupSecret() && !force {
-		return
+	// The language setting is not read yet
+	Language = GetLanguage("")
+
+	// Run the command given in the arguments and exit, the setup failures are reported as its result
+	oneShot := fs.NArg() != 0
+	if oneShot {
+		BeginCommandResult(fs.Arg(0), fs.Args()[1:])
 	}
 
-	fmt.Println(Yellow("Press enter to execute 'normal' command or previous command again (if any)."))
-	fmt.Println(Yellow("Use 'help' to see all commands."))
+	// The doctor command runs even if the environment is not set up
+	force = force || (oneShot && fs.Arg(0) == "doctor")
 
-	lastCommandLine := "normal"
-	reader := NonBlockingReader{}
-	reader.New()
-	reader.errFunc = func(err error) {
-		for e := RunningCommands.Front(); e != nil; e = e.Next() {
-			e.Value.(*exec.Cmd).Process.Kill()
+	ready := SetupEnvironment() || force
+	ready = ready && (SetupSecret() || force)
+	if !ready {
+		fmt.Fprintln(Console, InfoText(T("doctor-run")))
+		EndCommandResult(false)
+		os.Exit(1)
+	}
+	Language = GetLanguage(Secret["language"])
+	StartSessionLog()
+
+	// Ctrl+C at the prompt is handled by the line editor, a signal arrives only while a command is running
+	signals := make(chan os.Signal, 1)
+	signal.Notify(signals, os.Interrupt, syscall.SIGTERM)
+	go func() {
+		<-signals
+		KillRunningCommands()
+		os.Exit(130)
+	}()
+
+	if oneShot {
+		CurrentResult = nil
+		if !RunCommandLine(QuoteArgs(fs.Args())) {
+			os.Exit(1)
 		}
-		os.Exit(0)
+		return
 	}
+
+	fmt.Fprintln(Console, InfoText(T("repl-enter")))
+	fmt.Fprintln(Console, InfoText(T("repl-help")))
+
+	line := NewLineEditor()
+	defer line.Close()
+
+	lastCommandLine := "normal"
 	for {
-		for {
-			if _, err := reader.NonBlockingRead(); err == ErrNoData {
-				break
-			}
-		}
-		fmt.Print(Yellow("\n> "))
-		rawText, _ := reader.BlockingRead()
+		// Discard the keys pressed while the last command was running
+		FlushInput()
 
-		if len(rawText) == 0 { // EOF
+		fmt.Fprintln(Console)
+		rawText, err := line.Prompt(GetPrompt())
+		if err != nil { // EOF or Ctrl+C
+			KillRunningCommands()
 			break
 		}
 
 		commandLine := strings.TrimSpace(rawText)
 		if commandLine == "" {
 			commandLine = lastCommandLine
-			fmt.Println(Yellow("Execute last command: " + commandLine))
-		}
-
-		input, err := shlex.Split(commandLine)
-		if err != nil {
-			Fail(300)
-			continue
+			fmt.Fprintln(Console, InfoText(T("repl-repeat", commandLine)))
 		}
 
-		if len(input) == 0 {
-			continue
+		if ShouldSaveHistory(commandLine) {
+			line.AppendHistory(commandLine)
+			SaveReplHistory(line)
 		}
 
-		if HandleCommand(input[0], input[1:]) {
+		if RunCommandLine(commandLine) {
 			lastCommandLine = commandLine
 		}
 	}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"runtime"
 	"strconv"
 	"strings"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 )
@@ -68,6 +70,7 @@ func teardown() {
 	os.Remove("data.json")
 	os.Remove("project.pros")
 	os.Remove(".cmapi-cli-secret.json")
+	os.RemoveAll(".cmapi")
 	ExecCommand = exec.Command
 	MockCommandsQueue = []CommandSpec{}
 }
@@ -132,6 +135,16 @@ func TestUpdateFileSecret(t *testing.T) {
 	assert.Equal(t, expected, setB)
 }
 
+func TestShowSecretCommand(t *testing.T) {
+	BeginCommandResult("secret", []string{"email"})
+	defer EndCommandResult(false)
+
+	assert.Nil(t, ShowSecretCommand("email"))
+	assert.Equal(t, map[string]any{"secrets": map[string]string{"email": Secret["email"]}}, CurrentResult.Data)
+
+	assert.Equal(t, 130, GetErrorCode(ShowSecretCommand("unknown")))
+}
+
 func TestRunCommand(t *testing.T) {
 	setup()
 	defer teardown()
@@ -229,10 +242,10 @@ func TestLinkLocalRepoToServerCommand(t *testing.T) {
 		{"git config commit.gpgsign false", "", "", 0},
 	}
 
-	assert.False(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
-	assert.True(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
-	assert.True(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
-	assert.True(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
+	assert.Equal(t, 103, GetErrorCode(LinkLocalRepoToServerCommand(wd, "some-repo")))
+	assert.Nil(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
+	assert.Nil(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
+	assert.Nil(t, LinkLocalRepoToServerCommand(wd, "some-repo"))
 }
 
 func TestBackupCommand(t *testing.T) {
@@ -261,12 +274,43 @@ func TestBackupCommand(t *testing.T) {
 		{"git push -u origin master", "", "", 0},
 	}
 
-	assert.False(t, BackupCommand(wd))
-	assert.False(t, BackupCommand(wd))
-	assert.True(t, BackupCommand(wd))
+	assert.Equal(t, 105, GetErrorCode(BackupCommand(wd)))
+	assert.Equal(t, 106, GetErrorCode(BackupCommand(wd)))
+	assert.Nil(t, BackupCommand(wd))
 }
 
 func TestBeep(t *testing.T) {
 	BeepSuccess()
 	BeepFail()
 }
+
+func TestKillRunningCommands(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("sleep is not available on Windows")
+	}
+
+	cmd := exec.Command("sleep", "10")
+	done := make(chan int)
+	go func() {
+		done <- RunCommand(cmd)
+	}()
+
+	for {
+		RunningCommandsLock.Lock()
+		started := RunningCommands.Len() != 0
+		RunningCommandsLock.Unlock()
+		if started {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	KillRunningCommands()
+	select {
+	case code := <-done:
+		assert.NotEqual(t, 0, code)
+	case <-time.After(5 * time.Second):
+		t.Fatal("the command is not killed")
+	}
+	assert.Equal(t, 0, RunningCommands.Len())
+}
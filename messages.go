@@ -0,0 +1,222 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"strings"
+)
+
+// Language is the language of the messages, one of Languages
+var Language = "en"
+
+// Languages are the bundled languages
+var Languages = []string{"en", "zh-Hant"}
+
+// Messages are the English messages other than the errors in ErrorCode, keyed by message ID.
+// The help of a command and an option is in the registry, with the ID "help-<NAME>" and "option-<LONG>".
+var Messages = map[string]string{
+	"error":            "Error: %s",
+	"error-with-code":  "Error %d: %s",
+	"caused-by":        "    caused by: %s",
+	"alias-removed":    "Removed alias '%s'.",
+	"alias-set":        "Set alias '%s' = '%s'.",
+	"history-empty":    "No build or upload history yet.",
+	"kernel-pinned":    "Pinned the kernel to %s.",
+	"kernel-upgraded":  "Upgraded the kernel to %s.",
+	"kernel-mismatch":  "Warning: %s Use 'kernel upgrade' to apply it.",
+	"kernel-unknown":   "unknown",
+	"linked":           "Linked '%s' -> 'https://bitbucket.org/%s'.",
+	"backed-up":        "All changes have been backed up to the server.",
+	"initialized":      "Initialized PROS project at '%s'.",
+	"pulled":           "All changes have been pulled from the server.",
+	"cloned":           "Cloned 'https://bitbucket.org/%s' -> '%s'.",
+	"created":          "Created repository at '%s'.",
+	"templates-update": "Templates have been updated.",
+	"variants-built":   "Built %d variant(s).",
+	"variants-upload":  "Uploaded %d variant(s).",
+	"logs-empty":       "No logs for this session.",
+	"logs-session":     "Session started at %s",
+	"logs-exit":        "exit %d",
+	"logs-dir":         "    in %s",
+	"report-created":   "Created the report at '%s'.",
+	"group-project":    "Commands for project action:",
+	"group-repository": "Commands for repository management:",
+	"usage": "Usage: <command> [<args>, ...] [; | && <command> [<args>, ...]]\n\n" +
+		"Commands can be chained with ';' to run one after another, or with '&&' to run\n" +
+		"the next command only if the previous command succeeded.\n" +
+		"\nUnknown commands run the executable 'cmapi-cli-<command>' in the plugins\n" +
+		"directory of the administrator directory or in the PATH, if any.",
+	"usage-title":   "Usage:",
+	"usage-aliases": "Aliases: ",
+	"usage-options": "Options:",
+	"usage-version": "Version: ",
+
+	"doctor-hint":             "hint: %s",
+	"doctor-fixed":            "Fixed '%s'.",
+	"doctor-summary":          "%d passed, %d warning(s), %d failed.",
+	"doctor-run":              "Run 'doctor' to find out how to fix the environment.",
+	"check-tool-missing":      "%s is not installed or not in PATH.",
+	"check-tool-old":          "%s %s is older than %s.",
+	"check-tool-unknown":      "Cannot read the version of %s.",
+	"check-toolchain-unset":   "PROS_TOOLCHAIN is not set.",
+	"check-toolchain-missing": "'%s' is not a directory.",
+	"check-toolchain-no-gcc":  "'%s' does not contain the compiler.",
+	"check-admin-dir":         "Cannot write to '%s'.",
+	"check-secret-invalid":    "Cannot parse the secret file '%s'.",
+	"check-password-empty":    "The password is not set.",
+	"check-api-offline":       "Cannot connect to the Bitbucket API.",
+	"check-api-status":        "The Bitbucket API returned %s.",
+	"check-dialout":           "The user is not in the 'dialout' group.",
+	"check-no-device":         "No V5 Brain or controller is connected.",
+	"check-device-denied":     "Cannot read or write '%s'.",
+	"check-devices":           "%d device(s) found.",
+	"check-not-checked":       "Not checked on this platform.",
+	"hint-git":                "Install or upgrade Git from https://git-scm.com/downloads.",
+	"hint-pros":               "Install or upgrade PROS-CLI with 'pip install --upgrade pros-cli'.",
+	"hint-gcc":                "Install the PROS toolchain and add its 'bin' directory to PATH.",
+	"hint-toolchain":          "Set PROS_TOOLCHAIN to the directory of the PROS toolchain, which contains 'bin/arm-none-eabi-gcc'.",
+	"hint-export":             "Run 'doctor --fix' to add '%s' to '%s'.",
+	"hint-admin-dir":          "Check the permissions of '%s'.",
+	"hint-secret-invalid":     "Fix the JSON syntax of the file, or delete it to restore the defaults.",
+	"hint-password":           "Create an app password on Bitbucket and set it with 'secret password <PASSWORD>'.",
+	"hint-network":            "Check the Internet connection and the proxy settings.",
+	"hint-credentials":        "Check 'username', 'password' and 'workspace' with 'secret'.",
+	"hint-dialout":            "Run 'sudo usermod -a -G dialout $USER', then log out and in again.",
+	"hint-connect":            "Connect the V5 Brain or controller with a USB cable.",
+	"hint-device":             "Check the permissions of the device, or connect it again.",
+
+	"update-latest":      "CMAPI-CLI %s is the latest version.",
+	"update-available":   "CMAPI-CLI %s is available, the current version is %s. Run 'update' to install it.",
+	"update-downloading": "Downloading '%s'...",
+	"updated":            "Updated CMAPI-CLI from %s to %s. Restart it to use the new version.",
+
+	"projects-empty":            "No projects in '%s'.",
+	"projects-label":            "LABEL",
+	"projects-branch":           "BRANCH",
+	"projects-state":            "STATE",
+	"projects-last-commit":      "LAST COMMIT",
+	"projects-kernel":           "KERNEL",
+	"projects-last-build":       "LAST BUILD",
+	"state-no-git":              "no git",
+	"state-clean":               "clean",
+	"state-changed":             "%d changed",
+	"workspace-finished":        "Finished %s of '%s'.",
+	"workspace-done":            "All %d project(s) succeeded.",
+	"project-changed":           "The active project is '%s'.",
+	"recent-empty":              "No recent projects yet.",
+	"workspace-label":           "LABEL",
+	"workspace-result":          "RESULT",
+	"workspace-time":            "TIME",
+	"workspace-detail":          "DETAIL",
+	"workspace-ok":              "ok",
+	"workspace-failed":          "failed",
+	"workspace-nothing":         "nothing to back up",
+	"workspace-pushed":          "pushed %d commit(s)",
+	"workspace-kernel-mismatch": "kernel mismatch",
+
+	"template-up-to-date": "The project is up to date with the template at %s.",
+	"template-synced":     "Merged the template changes from %s to %s on branch '%s'.",
+	"template-conflict":   "Conflict: %s",
+
+	"renamed":        "Renamed '%s' -> '%s'.",
+	"renamed-remote": "Renamed 'https://bitbucket.org/%s' -> 'https://bitbucket.org/%s'.",
+	"archived":       "Moved 'https://bitbucket.org/%s' to project '%s'.",
+	"label-similar":  "No repository with label '%s', the similar label is '%s'.",
+	"label-confirm":  "Use '%s' instead?",
+
+	"resolved-label":      "Label: ",
+	"resolved-old-label":  "Old label: ",
+	"resolved-repository": "Repository: ",
+	"resolved-directory":  "Directory: ",
+
+	"secret-updated":     "Secret file updated.",
+	"secrets-listing":    "Listing secrets...",
+	"templates-listing":  "Listing templates...",
+	"template-installed": "%s (installed: %s)",
+	"template-missing":   "not installed",
+	"aliases-listing":    "Listing aliases...",
+	"variants-listing":   "Listing build variants...",
+	"variant-detail":     "slot %d, program '%s', flags '%s'",
+	"variants-uploading": "Uploading variant '%s' to slot %d",
+	"hook-running":       "Running hook '%s'",
+	"repl-enter":         "Press enter to execute 'normal' command or previous command again (if any).",
+	"repl-help":          "Use 'help' to see all commands.",
+	"repl-repeat":        "Execute last command: %s",
+	"upload-waiting":     "V5 product not found, retrying...",
+	"upload-starting":    "Starting to upload",
+	"upload-retrying":    "Upload failed, retrying... (%d/5)",
+	"history-recent":     "Recent runs:",
+	"history-averages":   "Averages by project:",
+	"history-ok":         "OK",
+	"history-failed":     "FAILED (make %d, upload %d)",
+
+	"status-project":          "Project: ",
+	"status-branch":           "Branch: ",
+	"status-changes":          "Uncommitted changes: ",
+	"status-not-git":          "not a git repository",
+	"status-name":             "Name: ",
+	"status-target":           "Target: ",
+	"status-template":         "Template: ",
+	"status-kernel-installed": "Installed kernel: ",
+	"status-kernel-pinned":    "Pinned kernel: ",
+}
+
+// Catalogues are the translations of the messages in the bundled languages other than English,
+// keyed by language and then message ID. The ID of an error is "error-<CODE>".
+var Catalogues = map[string]map[string]string{
+	"zh-Hant": MessagesZhHant,
+}
+
+// MatchLanguage returns the bundled language of the locale like "zh_TW.UTF-8" or "zh-Hant", or "en" if
+// the locale is not supported.
+// No side effect
+func MatchLanguage(locale string) string {
+	locale = strings.ToLower(strings.ReplaceAll(locale, "_", "-"))
+	if i := strings.IndexAny(locale, ".@"); i != -1 {
+		locale = locale[:i]
+	}
+
+	switch {
+	case locale == "zh-tw", locale == "zh-hk", locale == "zh-mo", strings.HasPrefix(locale, "zh-hant"):
+		return "zh-Hant"
+	default:
+		return "en"
+	}
+}
+
+// GetLanguage returns the language from the setting, or from the locale in the environment if the setting
+// is empty.
+// No side effect
+func GetLanguage(setting string) string {
+	for _, locale := range []string{setting, os.Getenv("LC_ALL"), os.Getenv("LC_MESSAGES"), os.Getenv("LANG")} {
+		if locale != "" {
+			return MatchLanguage(locale)
+		}
+	}
+	return "en"
+}
+
+// GetMessage returns the message of the ID in the current language, or the fallback if it is not translated.
+// No side effect
+func GetMessage(id string, fallback string) string {
+	if message, ok := Catalogues[Language][id]; ok {
+		return message
+	}
+	return fallback
+}
+
+// T returns the message of the ID in the current language, formatted with the arguments.
+// No side effect
+func T(id string, args ...any) string {
+	message := GetMessage(id, Messages[id])
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
+
+// GetErrorMessage returns the message of the error code in the current language, formatted with the arguments.
+// No side effect
+func GetErrorMessage(code int, args ...any) string {
+	return fmt.Sprintf(GetMessage(fmt.Sprintf("error-%d", code), ErrorCode[code]), args...)
+}
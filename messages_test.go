@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var formatVerbs = regexp.MustCompile(`%[-+# 0-9.]*[a-zA-Z%]`)
+
+// assertTranslated checks that the message is translated with the same format verbs in the same order
+func assertTranslated(t *testing.T, language string, id string, english string) {
+	translated, ok := Catalogues[language][id]
+	if assert.True(t, ok, "%s: missing translation of '%s'", language, id) {
+		assert.Equal(t, formatVerbs.FindAllString(english, -1), formatVerbs.FindAllString(translated, -1),
+			"%s: format arguments of '%s' differ", language, id)
+	}
+}
+
+func TestCataloguesComplete(t *testing.T) {
+	for _, language := range Languages {
+		if language == "en" {
+			continue
+		}
+
+		for code, english := range ErrorCode {
+			assertTranslated(t, language, fmt.Sprintf("error-%d", code), english)
+		}
+		for id, english := range Messages {
+			assertTranslated(t, language, id, english)
+		}
+		for _, cmd := range Commands {
+			assertTranslated(t, language, "help-"+cmd.Name, cmd.Help)
+		}
+		for _, option := range Options {
+			assertTranslated(t, language, "option-"+option.Long, option.Help)
+		}
+	}
+}
+
+func TestMatchLanguage(t *testing.T) {
+	assert.Equal(t, "zh-Hant", MatchLanguage("zh_TW.UTF-8"))
+	assert.Equal(t, "zh-Hant", MatchLanguage("zh_HK"))
+	assert.Equal(t, "zh-Hant", MatchLanguage("zh-Hant"))
+	assert.Equal(t, "zh-Hant", MatchLanguage("zh_Hant_TW@calendar"))
+	assert.Equal(t, "en", MatchLanguage("zh_CN.UTF-8"))
+	assert.Equal(t, "en", MatchLanguage("en_US.UTF-8"))
+	assert.Equal(t, "en", MatchLanguage("C"))
+}
+
+func TestGetLanguage(t *testing.T) {
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LC_MESSAGES", "")
+	t.Setenv("LANG", "zh_TW.UTF-8")
+	assert.Equal(t, "zh-Hant", GetLanguage(""))
+	assert.Equal(t, "en", GetLanguage("en"))
+
+	t.Setenv("LC_ALL", "en_US.UTF-8")
+	assert.Equal(t, "en", GetLanguage(""))
+
+	t.Setenv("LC_ALL", "")
+	t.Setenv("LANG", "")
+	assert.Equal(t, "en", GetLanguage(""))
+}
+
+func TestTranslatedMessages(t *testing.T) {
+	defer func() { Language = "en" }()
+
+	assert.Equal(t, "Built 2 variant(s).", T("variants-built", 2))
+	assert.Equal(t, "Secret key 'x' does not exist.", NewError(130, "x").Error())
+	assert.Contains(t, GetUsage(), "Commands for project action:")
+
+	Language = "zh-Hant"
+	assert.Equal(t, "已編譯 2 個建置變體。", T("variants-built", 2))
+	assert.Equal(t, "槽位 1，程式 'Left'，旗標 '-DAUTON_LEFT'", T("variant-detail", 1, "Left", "-DAUTON_LEFT"))
+	assert.Equal(t, "3.8.0（已安裝：未安裝）", T("template-installed", "3.8.0", T("template-missing")))
+	assert.Equal(t, "密鑰 'x' 不存在。", NewError(130, "x").Error())
+	assert.Contains(t, GetUsage(), "專案操作指令：")
+
+	// Untranslated messages fall back to English
+	assert.Equal(t, "fallback", GetMessage("no-such-message", "fallback"))
+}
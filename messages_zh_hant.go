@@ -0,0 +1,331 @@
+package main
+
+// MessagesZhHant are the messages in Traditional Chinese, keyed by message ID
+var MessagesZhHant = map[string]string{
+	"error":            "錯誤：%s",
+	"error-with-code":  "錯誤 %d：%s",
+	"caused-by":        "    原因：%s",
+	"alias-removed":    "已移除別名 '%s'。",
+	"alias-set":        "已設定別名 '%s' = '%s'。",
+	"history-empty":    "尚未有任何編譯或上傳紀錄。",
+	"kernel-pinned":    "已將核心固定為 %s。",
+	"kernel-upgraded":  "已將核心升級至 %s。",
+	"kernel-mismatch":  "警告：%s 請使用 'kernel upgrade' 套用。",
+	"kernel-unknown":   "未知",
+	"linked":           "已連結 '%s' -> 'https://bitbucket.org/%s'。",
+	"backed-up":        "所有變更已備份至伺服器。",
+	"initialized":      "已於 '%s' 初始化 PROS 專案。",
+	"pulled":           "已從伺服器拉取所有變更。",
+	"cloned":           "已複製 'https://bitbucket.org/%s' -> '%s'。",
+	"created":          "已於 '%s' 建立儲存庫。",
+	"templates-update": "模板已更新。",
+	"variants-built":   "已編譯 %d 個建置變體。",
+	"variants-upload":  "已上傳 %d 個建置變體。",
+	"logs-empty":       "此工作階段沒有紀錄。",
+	"logs-session":     "工作階段開始於 %s",
+	"logs-exit":        "結束碼 %d",
+	"logs-dir":         "    位於 %s",
+	"report-created":   "已於 '%s' 建立報告。",
+	"group-project":    "專案操作指令：",
+	"group-repository": "儲存庫管理指令：",
+	"usage": "用法：<指令> [<參數>, ...] [; | && <指令> [<參數>, ...]]\n\n" +
+		"指令可以用 ';' 串連以逐一執行，或用 '&&' 串連，\n" +
+		"只在前一個指令成功時才執行下一個指令。\n" +
+		"\n未知的指令會執行管理員目錄中 plugins 目錄或 PATH 內的\n" +
+		"'cmapi-cli-<指令>' 執行檔（如有）。",
+	"usage-title":   "用法：",
+	"usage-aliases": "別名：",
+	"usage-options": "選項：",
+	"usage-version": "版本：",
+
+	"doctor-hint":             "提示：%s",
+	"doctor-fixed":            "已修正 '%s'。",
+	"doctor-summary":          "%d 項通過，%d 項警告，%d 項失敗。",
+	"doctor-run":              "請執行 'doctor' 以了解如何修正環境。",
+	"check-tool-missing":      "%s 未安裝或不在 PATH 中。",
+	"check-tool-old":          "%s %s 比 %s 舊。",
+	"check-tool-unknown":      "無法讀取 %s 的版本。",
+	"check-toolchain-unset":   "未設定 PROS_TOOLCHAIN。",
+	"check-toolchain-missing": "'%s' 不是目錄。",
+	"check-toolchain-no-gcc":  "'%s' 中沒有編譯器。",
+	"check-admin-dir":         "無法寫入 '%s'。",
+	"check-secret-invalid":    "無法解析密鑰檔案 '%s'。",
+	"check-password-empty":    "未設定密碼。",
+	"check-api-offline":       "無法連接 Bitbucket API。",
+	"check-api-status":        "Bitbucket API 回傳 %s。",
+	"check-dialout":           "使用者不屬於 'dialout' 群組。",
+	"check-no-device":         "未連接 V5 主機或遙控器。",
+	"check-device-denied":     "無法讀寫 '%s'。",
+	"check-devices":           "找到 %d 個裝置。",
+	"check-not-checked":       "此平台不支援檢查。",
+	"hint-git":                "從 https://git-scm.com/downloads 安裝或升級 Git。",
+	"hint-pros":               "使用 'pip install --upgrade pros-cli' 安裝或升級 PROS-CLI。",
+	"hint-gcc":                "安裝 PROS 工具鏈，並將其 'bin' 目錄加入 PATH。",
+	"hint-toolchain":          "將 PROS_TOOLCHAIN 設定為 PROS 工具鏈的目錄，即包含 'bin/arm-none-eabi-gcc' 的目錄。",
+	"hint-export":             "執行 'doctor --fix' 以將 '%s' 加入 '%s'。",
+	"hint-admin-dir":          "檢查 '%s' 的權限。",
+	"hint-secret-invalid":     "修正檔案的 JSON 語法，或刪除檔案以還原預設值。",
+	"hint-password":           "在 Bitbucket 建立應用程式密碼，並以 'secret password <密碼>' 設定。",
+	"hint-network":            "檢查網路連線及代理伺服器設定。",
+	"hint-credentials":        "使用 'secret' 檢查 'username'、'password' 及 'workspace'。",
+	"hint-dialout":            "執行 'sudo usermod -a -G dialout $USER'，然後登出並重新登入。",
+	"hint-connect":            "以 USB 線連接 V5 主機或遙控器。",
+	"hint-device":             "檢查裝置的權限，或重新連接裝置。",
+
+	"update-latest":      "CMAPI-CLI %s 已是最新版本。",
+	"update-available":   "有新版本 CMAPI-CLI %s，目前版本為 %s。執行 'update' 以安裝。",
+	"update-downloading": "正在下載 '%s'...",
+	"updated":            "已將 CMAPI-CLI 從 %s 更新至 %s。請重新啟動以使用新版本。",
+
+	"projects-empty":            "'%s' 中沒有專案。",
+	"projects-label":            "標籤",
+	"projects-branch":           "分支",
+	"projects-state":            "狀態",
+	"projects-last-commit":      "最後提交",
+	"projects-kernel":           "核心",
+	"projects-last-build":       "最後建置",
+	"state-no-git":              "沒有 Git",
+	"state-clean":               "無變更",
+	"state-changed":             "%d 個變更",
+	"workspace-finished":        "已完成 %s '%s'。",
+	"workspace-done":            "全部 %d 個專案皆成功。",
+	"project-changed":           "目前的專案為 '%s'。",
+	"recent-empty":              "尚未有最近使用的專案。",
+	"workspace-label":           "標籤",
+	"workspace-result":          "結果",
+	"workspace-time":            "時間",
+	"workspace-detail":          "詳情",
+	"workspace-ok":              "成功",
+	"workspace-failed":          "失敗",
+	"workspace-nothing":         "沒有需要備份的變更",
+	"workspace-pushed":          "已推送 %d 個提交",
+	"workspace-kernel-mismatch": "核心不符",
+
+	"template-up-to-date": "專案已與模板 %s 同步。",
+	"template-synced":     "已合併模板從 %s 至 %s 的變更，位於分支 '%s'。",
+	"template-conflict":   "衝突：%s",
+
+	"renamed":        "已重新命名 '%s' -> '%s'。",
+	"renamed-remote": "已重新命名 'https://bitbucket.org/%s' -> 'https://bitbucket.org/%s'。",
+	"archived":       "已將 'https://bitbucket.org/%s' 移至專案 '%s'。",
+	"label-similar":  "沒有標籤為 '%s' 的儲存庫，相似的標籤為 '%s'。",
+	"label-confirm":  "改用 '%s'？",
+
+	"resolved-label":      "標籤：",
+	"resolved-old-label":  "舊標籤：",
+	"resolved-repository": "儲存庫：",
+	"resolved-directory":  "目錄：",
+
+	"secret-updated":     "密鑰檔案已更新。",
+	"secrets-listing":    "正在列出密鑰...",
+	"templates-listing":  "正在列出模板...",
+	"template-installed": "%s（已安裝：%s）",
+	"template-missing":   "未安裝",
+	"aliases-listing":    "正在列出別名...",
+	"variants-listing":   "正在列出建置變體...",
+	"variant-detail":     "槽位 %d，程式 '%s'，旗標 '%s'",
+	"variants-uploading": "正在將建置變體 '%s' 上傳至槽位 %d",
+	"hook-running":       "正在執行掛鉤 '%s'",
+	"repl-enter":         "按 Enter 執行 'normal' 指令，或再次執行上一個指令（如有）。",
+	"repl-help":          "使用 'help' 查看所有指令。",
+	"repl-repeat":        "執行上一個指令：%s",
+	"upload-waiting":     "找不到 V5 裝置，重試中...",
+	"upload-starting":    "開始上傳",
+	"upload-retrying":    "上傳失敗，重試中...（%d/5）",
+	"history-recent":     "最近的執行：",
+	"history-averages":   "各專案的平均：",
+	"history-ok":         "成功",
+	"history-failed":     "失敗（make %d，upload %d）",
+
+	"status-project":          "專案：",
+	"status-branch":           "分支：",
+	"status-changes":          "未提交的變更：",
+	"status-not-git":          "不是 Git 儲存庫",
+	"status-name":             "名稱：",
+	"status-target":           "目標：",
+	"status-template":         "模板：",
+	"status-kernel-installed": "已安裝的核心：",
+	"status-kernel-pinned":    "固定的核心：",
+
+	"help-all": "刪除專案 ./bin 目錄中所有目的檔並重新編譯所有原始碼。\n" +
+		"嘗試連接 V5 主機並上傳二進位檔。",
+	"help-b":      "在目前的 PROS 專案中正常編譯原始碼，但不上傳。",
+	"help-backup": "提交儲存庫中所有變更並推送至遠端伺服器。",
+	"help-init": "初始化 Git 儲存庫並建立 PROS 專案。\n" +
+		"套用核心至專案，不會覆寫任何現有檔案。",
+	"help-kernel": "檢查已安裝的核心是否與專案設定中固定的版本相符、\n" +
+		"列出可用的核心、固定核心版本，或套用固定、指定或最新的核心。\n" +
+		"若專案無法以新核心編譯，專案會被還原。",
+	"help-link": "將目前目錄連結至 Bitbucket 上的遠端儲存庫。\n" +
+		"專案代號預設與專案根目錄名稱相同。",
+	"help-normal": "在目前的 PROS 專案中正常編譯原始碼。\n" +
+		"嘗試連接 V5 主機並上傳二進位檔。",
+	"help-pull":   "從遠端伺服器拉取變更至本機儲存庫。",
+	"help-status": "顯示專案的分支、未提交的變更及核心。",
+	"help-template": "列出、新增、移除或更新專案依賴的模板（例如 okapilib、LemLib）。\n" +
+		"模板會記錄在專案設定中，並在複製或初始化專案時重新套用。",
+	"help-template-sync": "將專案建立或上次同步後模板儲存庫的變更合併到專案的新分支。\n" +
+		"衝突會保留在檔案中，需手動解決並提交。",
+	"help-variants": "列出專案設定中宣告的建置變體，或以各自的編譯期定義\n" +
+		"將每個變體編譯至 'bin/variants/<NAME>'。'upload' 動作會\n" +
+		"另外將每個變體上傳至各自的槽位。若未指定名稱，則選取所有變體。",
+	"help-clone": "1. 從伺服器複製儲存庫至本機。\n" +
+		"2. 初始化 PROS 專案並套用已記錄的模板。",
+	"help-create": "1. 在本機建立儲存庫。標籤會轉為大寫，空格及底線會轉為連字號。\n" +
+		"2. 從模板複製所有內容，並替換檔案內容及名稱中如 {{label}}\n" +
+		"的預留位置。\n" +
+		"3. 初始化 PROS 專案。\n" +
+		"4. 上傳儲存庫至伺服器。",
+	"help-rename": "將本機專案移至新標籤的目錄，在伺服器上重新命名儲存庫，\n" +
+		"將專案連結至重新命名的儲存庫並提交新的專案名稱。",
+	"help-archive": "將伺服器上的儲存庫移至封存專案並移除本機專案。\n" +
+		"若本機專案有尚未推送至伺服器的變更、儲藏或提交，則不會做任何變更。",
+	"help-projects": "列出工作區目錄中的專案，以及其分支、未提交的變更、\n" +
+		"領先及落後伺服器的提交、最後提交、核心及最後一次成功編譯。",
+	"help-workspace": "拉取、備份或顯示工作區目錄中每個儲存庫的狀態，\n" +
+		"同時處理數個專案。一個專案失敗不會中止其他專案。結果會在最後以表格顯示。",
+	"help-cd": "將目錄或工作區目錄中具有該標籤的專案設為目前的專案。\n" +
+		"相對路徑以目前的專案為起點。",
+	"help-open": "將工作區目錄中具有該標籤的專案設為目前的專案。\n" +
+		"若專案不存在，會先從伺服器複製。",
+	"help-recent": "列出最近曾為目前專案的專案。",
+	"help-alias": "列出所有別名、顯示一個別名，或將指令列儲存為別名。\n" +
+		"若指令列包含 ';' 或 '&&'，請加上引號。空白的指令會移除別名。\n" +
+		"傳給別名的額外參數會附加在其指令列之後。",
+	"help-help": "顯示此說明，或某個指令的說明。",
+	"help-history": "顯示最近的編譯及上傳紀錄，以及每個專案的平均耗時。\n" +
+		"[預設：10]",
+	"help-logs": "顯示倒數第 N 個工作階段中執行的 Git、PROS 及其他指令，\n" +
+		"以及其結束代碼和輸出。[預設：1，即目前的工作階段]",
+	"help-report": "將紀錄、不含密碼的設定及工具版本打包成 zip 檔，\n" +
+		"以便附加至問題回報。",
+	"help-doctor": "檢查工具、PROS_TOOLCHAIN、管理員目錄、密鑰檔案、\n" +
+		"憑證及序列埠裝置，並顯示如何修正找到的問題。",
+	"help-update": "檢查 GitHub 上 CMAPI-CLI 的最新版本，若較新則下載並安裝。\n" +
+		"'check' 動作只顯示是否有較新的版本。",
+	"help-secret": "列出所有密鑰及其值、顯示密鑰的值，或設定密鑰的值。",
+
+	"option-color":     "何時為輸出上色。[預設：auto，\n可選：auto、always、never]",
+	"option-directory": "工作區目錄，即所有儲存庫所在的上層目錄。\n[預設：預設設定]",
+	"option-fix":       "套用安全的修正，例如將環境變數加入\nShell 啟動檔。",
+	"option-force":     "強制執行動作。",
+	"option-kernel":    "使用的核心版本。[預設：latest]",
+	"option-local":     "不在伺服器上建立儲存庫。",
+	"option-no-pull":   "不在線上拉取模板變更／核心。",
+	"option-output":    "使用 'json' 時，每個指令輸出一個 JSON 物件，\n其他訊息輸出至 stderr。\n[預設：text，可選：text、json]",
+	"option-slot":      "將二進位檔上傳至主機中指定的程式槽位。\n[預設：1，範圍：1-8]",
+	"option-template":  "建立專案所用的模板，須為設定中的模板之一。\n[預設：模板儲存庫]",
+	"option-verbose":   "顯示錯誤的原因。",
+	"option-yes":       "直接使用伺服器上儲存庫的相似標籤，不再詢問。",
+
+	"error-100": "Git 未安裝或不在 PATH 中。",
+	"error-101": "PROS 未安裝或不在 PATH 中。",
+	"error-102": "不是 Git 儲存庫。",
+	"error-103": "無法連結遠端儲存庫。",
+	"error-104": "無法設定 Git 設定。",
+	"error-105": "無法建立備份提交。",
+	"error-106": "無法推送備份提交。",
+	"error-107": "編譯失敗。",
+	"error-108": "上傳失敗。",
+	"error-109": "PROS 專案已存在。使用 --force 覆寫 project.pros 檔案。",
+	"error-110": "無法建立初始提交。",
+	"error-111": "無法重設至初始提交。",
+	"error-112": "拉取失敗。",
+	"error-113": "無法建立專案目錄 '%s'。",
+	"error-114": "複製失敗。",
+	"error-115": "無法複製模板儲存庫。",
+	"error-116": "無法連結模板儲存庫。",
+	"error-117": "本機的 '%s' 中找不到模板儲存庫。",
+	"error-118": "儲存庫已存在。",
+	"error-119": "無法複製模板儲存庫的內容。",
+	"error-120": "無法建立遠端儲存庫。",
+	"error-121": "無法建立遠端儲存庫，狀態為 %s。",
+	"error-122": "無法推送至伺服器。",
+	"error-123": "無法初始化 Git 儲存庫。",
+	"error-124": "無法寫入 project.pros",
+	"error-125": "無法安裝核心。",
+	"error-126": "無法讀取密鑰檔案。",
+	"error-127": "無法取得使用者資訊。",
+	"error-128": "無法存取管理員目錄。",
+	"error-129": "無法取得工作目錄。",
+	"error-130": "密鑰 '%s' 不存在。",
+	"error-131": "無法重設至最新的提交。",
+	"error-132": "未定義 'PROS_TOOLCHAIN' 環境變數。",
+	"error-133": "使用者應屬於 'dialout' 群組。",
+	"error-134": "不是 PROS 專案，請使用 'init' 指令初始化。",
+	"error-135": "無法讀取專案設定檔。",
+	"error-136": "專案設定中未宣告任何建置變體。",
+	"error-137": "建置變體 '%s' 不存在。",
+	"error-138": "無效的建置變體名稱 '%s'，只接受字母、數字、底線及連字號。",
+	"error-139": "建置變體 '%s' 的槽位 %d 無效，範圍為 1-8。",
+	"error-140": "無法編譯建置變體 '%s'。",
+	"error-141": "無法上傳建置變體 '%s'。",
+	"error-142": "已安裝的核心 %s 與固定的核心 %s 不相符。",
+	"error-143": "無法查詢核心模板。",
+	"error-144": "無法寫入專案設定檔。",
+	"error-145": "有未提交的變更，請先使用 'backup' 指令提交。",
+	"error-146": "無法套用核心 %s，專案已還原。",
+	"error-147": "無法以新核心編譯，專案已還原。",
+	"error-148": "無法以新核心編譯，且無法還原專案。",
+	"error-149": "無法提交核心升級。",
+	"error-150": "無法套用模板 %s。",
+	"error-151": "無法移除模板 '%s'。",
+	"error-152": "模板 '%s' 未記錄在專案設定中。",
+	"error-153": "無法讀取 project.pros：%s。",
+	"error-154": "無法寫入別名檔案。",
+	"error-155": "外掛 '%s' 以代碼 %d 結束。",
+	"error-156": "掛鉤 '%s' 失敗，指令已中止。",
+	"error-157": "掛鉤 '%s' 失敗。",
+	"error-158": "無法讀取紀錄檔。",
+	"error-159": "無法建立報告。",
+	"error-160": "環境中找到 %d 個問題。",
+	"error-161": "無法修正 '%s'。",
+	"error-162": "無法檢查最新版本。",
+	"error-163": "此平台沒有發佈檔案 '%s'。",
+	"error-164": "無法下載 '%s'。",
+	"error-165": "發佈中沒有 '%s' 的校驗碼。",
+	"error-166": "'%s' 的校驗碼不符，已捨棄下載的檔案。",
+	"error-167": "無法取代執行檔 '%s'。",
+	"error-168": "無法讀取工作區目錄 '%s'。",
+	"error-169": "%d 個專案失敗，共 %d 個。",
+	"error-170": "沒有標籤為 '%s' 的目錄或專案。",
+	"error-171": "無法切換至 '%s'。",
+	"error-172": "專案設定中沒有記錄模板提交，此專案並非由模板建立。",
+	"error-173": "專案有未提交的變更，請先提交或備份。",
+	"error-174": "無法將模板儲存庫擷取到專案中。",
+	"error-175": "無法比較模板從 %s 至 %s 的差異。",
+	"error-176": "無法建立分支 '%s'。",
+	"error-177": "無法在分支 '%s' 套用模板的變更。",
+	"error-178": "%d 個檔案在分支 '%s' 有衝突，請解決後提交。",
+	"error-179": "未知的模板 '%s'，請將其加入 'templates' 密鑰，例如 'secret templates \"lemlib-template, ~/templates/skills\"'。",
+	"error-180": "無效的模板描述檔 '%s'。",
+	"error-181": "'%s' 已存在。",
+	"error-182": "無法重新命名遠端儲存庫 '%s'。",
+	"error-183": "無法將 '%s' 移至 '%s'。",
+	"error-184": "無法提交重新命名。",
+	"error-185": "'%s' 有 %d 個未提交的變更、%d 個儲藏及 %d 個尚未推送至伺服器的提交，請先備份。",
+	"error-186": "無法將遠端儲存庫 '%s' 移至專案 '%s'。",
+	"error-187": "無法移除 '%s'。",
+	"error-188": "伺服器上沒有標籤為 '%s' 或相似標籤的儲存庫。",
+	"error-189": "標籤 '%s' 與伺服器上多個儲存庫相似：%s。",
+	"error-190": "無法檢查 '%s' 是否有尚未推送至伺服器的變更。",
+	"error-191": "遠端儲存庫已重新命名為 '%s'，但 '%s' 的專案尚未更新。",
+	"error-192": "未接受相似的標籤 '%s'，請使用 '--yes' 直接接受。",
+	"error-193": "無法套用核心 %s，且無法還原專案。",
+	"error-200": "無效的標籤，只接受大寫字母、數字及連字號。",
+	"error-201": "未知的動作 '%s'。",
+	"error-202": "無效的數量 '%s'，應為正整數。",
+	"error-203": "無效的核心版本 '%s'，應為類似 3.8.0 的版本。",
+	"error-204": "無效的模板 '%s'，應為類似 okapilib@4.8.0 的模板。",
+	"error-205": "請使用 'kernel' 指令管理核心。",
+	"error-206": "無效的別名 '%s'，只接受字母、數字、底線及連字號。",
+	"error-207": "別名 '%s' 已是一個指令。",
+	"error-208": "別名 '%s' 引用其他別名的層數過深。",
+	"error-209": "別名 '%s' 不存在。",
+	"error-210": "無效的輸出格式 '%s'，應為 text 或 json。",
+	"error-211": "無效的上色模式 '%s'，應為 auto、always 或 never。",
+	"error-300": "無法解析指令列。",
+	"error-301": "未知的指令 '%s'。",
+	"error-302": "指令 '%s' 的選項無效：%s。",
+	"error-303": "指令 '%s' 的參數數量錯誤，請使用 'help %s' 查看用法。",
+}
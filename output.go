@@ -0,0 +1,118 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+)
+
+// CommandResult is the machine-readable result of a command, printed as one JSON object in the json output format
+type CommandResult struct {
+	Command   string         `json:"command"`
+	Args      []string       `json:"args"`
+	Success   bool           `json:"success"`
+	ErrorCode int            `json:"error-code,omitempty"`
+	Message   string         `json:"message,omitempty"`
+	Causes    []string       `json:"causes,omitempty"`
+	Data      map[string]any `json:"data,omitempty"`
+	StartedAt time.Time      `json:"started-at"`
+	Seconds   float64        `json:"seconds"`
+}
+
+var (
+	// OutputFormat is the current output format, "text" or "json"
+	OutputFormat = "text"
+	// Console is where the human-readable messages and the output of child processes go.
+	// It is stderr in the json output format so that stdout only has the results
+	Console io.Writer = os.Stdout
+	// CurrentResult is the result of the running command, nil if no command is running
+	CurrentResult *CommandResult
+)
+
+// SetOutputFormat sets the output format and where the human-readable messages go.
+// Returns false if the format is unknown
+func SetOutputFormat(format string) bool {
+	switch format {
+	case "text":
+		Console = os.Stdout
+	case "json":
+		Console = os.Stderr
+	default:
+		return false
+	}
+	OutputFormat = format
+	return true
+}
+
+// BeginCommandResult starts collecting the result of the command
+func BeginCommandResult(command string, args []string) *CommandResult {
+	CurrentResult = &CommandResult{
+		Command:   command,
+		Args:      args,
+		Data:      map[string]any{},
+		StartedAt: time.Now(),
+	}
+	return CurrentResult
+}
+
+// EndCommandResult finishes the result of the running command and prints it in the json output format
+func EndCommandResult(success bool) {
+	result := CurrentResult
+	if result == nil {
+		return
+	}
+	CurrentResult = nil
+
+	result.Success = success
+	result.Seconds = time.Since(result.StartedAt).Seconds()
+
+	if OutputFormat == "json" {
+		if output, err := json.Marshal(result); err == nil {
+			fmt.Fprintln(os.Stdout, string(output))
+		}
+	}
+}
+
+// ReportData adds the value to the data of the result of the running command
+func ReportData(key string, value any) {
+	if CurrentResult != nil {
+		CurrentResult.Data[key] = value
+	}
+}
+
+// recordError records the last reported error of the running command
+func recordError(code int, message string, causes []string) {
+	if CurrentResult != nil {
+		CurrentResult.ErrorCode = code
+		CurrentResult.Message = message
+		CurrentResult.Causes = causes
+	}
+}
+
+// recordMessage records the success message of the running command
+func recordMessage(message string) {
+	if CurrentResult != nil && CurrentResult.ErrorCode == 0 {
+		CurrentResult.Message = message
+	}
+}
+
+// RedactSecret returns the value of the secret, with the credentials hidden.
+// No side effect
+func RedactSecret(key string, value string) string {
+	if key == "password" && value != "" {
+		return "********"
+	}
+	return value
+}
+
+// RedactSecrets returns a copy of the secrets, with the credentials hidden.
+// No side effect
+func RedactSecrets(secret map[string]string) map[string]string {
+	rtn := map[string]string{}
+	for key, value := range secret {
+		rtn[key] = RedactSecret(key, value)
+	}
+	return rtn
+}
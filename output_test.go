@@ -0,0 +1,111 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// captureStdout returns what the function prints to stdout
+func captureStdout(f func()) string {
+	r, w, _ := os.Pipe()
+	stdout := os.Stdout
+	os.Stdout = w
+	f()
+	os.Stdout = stdout
+	w.Close()
+
+	output, _ := io.ReadAll(r)
+	return string(output)
+}
+
+func TestSetOutputFormat(t *testing.T) {
+	defer SetOutputFormat("text")
+
+	assert.True(t, SetOutputFormat("json"))
+	assert.Equal(t, "json", OutputFormat)
+	assert.Equal(t, os.Stderr, Console)
+
+	assert.False(t, SetOutputFormat("yaml"))
+	assert.Equal(t, "json", OutputFormat)
+
+	assert.True(t, SetOutputFormat("text"))
+	assert.Equal(t, os.Stdout, Console)
+}
+
+func TestCommandResult(t *testing.T) {
+	BeginCommandResult("test", []string{"a"})
+	ReportData("key", 1)
+	ReportError(NewError(201, "y"))
+	ReportError(WrapError(errors.New("cause"), 130, "x"))
+	assert.Equal(t, 130, CurrentResult.ErrorCode)
+	assert.Equal(t, "Secret key 'x' does not exist.", CurrentResult.Message)
+	assert.Equal(t, []string{"cause"}, CurrentResult.Causes)
+	assert.Equal(t, map[string]any{"key": 1}, CurrentResult.Data)
+
+	// Not printed in the text output format
+	output := captureStdout(func() { EndCommandResult(false) })
+	assert.Nil(t, CurrentResult)
+	assert.NotContains(t, output, "{")
+
+	// Nothing is recorded if no command is running
+	ReportData("key", 1)
+	assert.Nil(t, Success("Done."))
+	assert.Nil(t, CurrentResult)
+}
+
+func TestHandleCommandJsonOutput(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	AdminDir = wd
+	defer os.Remove(GetAliasFilePath())
+
+	assert.Nil(t, AliasCommand([]string{"up=pull"}))
+
+	var result CommandResult
+	output := captureStdout(func() {
+		assert.True(t, HandleCommand("alias", []string{"--output", "json"}))
+	})
+	assert.Nil(t, json.Unmarshal([]byte(output), &result))
+	assert.Equal(t, "alias", result.Command)
+	assert.Equal(t, []string{"--output", "json"}, result.Args)
+	assert.True(t, result.Success)
+	assert.Equal(t, map[string]any{"aliases": map[string]any{"up": "pull"}}, result.Data)
+
+	// The option applies to the command only
+	assert.Equal(t, "text", OutputFormat)
+
+	SetOutputFormat("json")
+	defer SetOutputFormat("text")
+
+	output = captureStdout(func() {
+		assert.False(t, RunCommandLine("alias nope ; unknown"))
+	})
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	assert.Equal(t, 2, len(lines))
+	assert.Nil(t, json.Unmarshal([]byte(lines[0]), &result))
+	assert.False(t, result.Success)
+	assert.Equal(t, 209, result.ErrorCode)
+	assert.Nil(t, json.Unmarshal([]byte(lines[1]), &result))
+	assert.Equal(t, "unknown", result.Command)
+	assert.Equal(t, 301, result.ErrorCode)
+
+	assert.False(t, HandleCommand("alias", []string{"--output", "yaml"}))
+}
+
+func TestRedactSecret(t *testing.T) {
+	assert.Equal(t, "********", RedactSecret("password", "hunter2"))
+	assert.Equal(t, "", RedactSecret("password", ""))
+	assert.Equal(t, "vex7984", RedactSecret("workspace", "vex7984"))
+
+	secret := map[string]string{"password": "hunter2", "workspace": "vex7984"}
+	assert.Equal(t, map[string]string{"password": "********", "workspace": "vex7984"}, RedactSecrets(secret))
+	assert.Equal(t, "hunter2", secret["password"])
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+)
+
+// The prefix of the executable names of external subcommands
+const PluginPrefix = "cmapi-cli-"
+
+// Returns true if the given name can be the name of a plugin
+// No side effect
+var IsValidPluginName = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`).MatchString
+
+// GetPluginDir returns the directory of the plugins in the administrator directory.
+// No side effect
+func GetPluginDir() string {
+	return filepath.Join(AdminDir, "plugins")
+}
+
+// FindPlugin returns the path of the executable of the external subcommand, or an empty string if not found.
+// The plugin directory is searched before the PATH.
+func FindPlugin(name string) string {
+	if !IsValidPluginName(name) {
+		return ""
+	}
+
+	filename := PluginPrefix + name
+	if runtime.GOOS == "windows" {
+		filename += ".exe"
+	}
+
+	if AdminDir != "" {
+		path := filepath.Join(GetPluginDir(), filename)
+		if info, err := os.Stat(path); err == nil && info.Mode().IsRegular() &&
+			(runtime.GOOS == "windows" || info.Mode().Perm()&0111 != 0) {
+			return path
+		}
+	}
+
+	if path, err := exec.LookPath(filename); err == nil {
+		return path
+	}
+
+	return ""
+}
+
+// GetPluginEnv returns the environment variables passed to plugins, with the secrets as the config.
+// No side effect
+func GetPluginEnv(projectRoot string, secret map[string]string) []string {
+	config, _ := json.Marshal(secret)
+
+	return []string{
+		"CMAPI_CLI_VERSION=" + Version,
+		"CMAPI_CLI_CONFIG=" + string(config),
+		"CMAPI_CLI_PROFILE=" + SecretFilePath,
+		"CMAPI_CLI_ADMIN_DIR=" + AdminDir,
+		"CMAPI_CLI_PROJECT_ROOT=" + projectRoot,
+		"CMAPI_CLI_OUTPUT=" + OutputFormat,
+	}
+}
+
+// RunPluginCommand runs the external subcommand in the project root
+func RunPluginCommand(path string, projectRoot string, args []string) error {
+	cmd := ExecCommand(path, args...)
+	cmd.Dir = projectRoot
+	if cmd.Env == nil {
+		cmd.Env = os.Environ()
+	}
+	cmd.Env = append(cmd.Env, GetPluginEnv(projectRoot, Secret)...)
+
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = Console
+	cmd.Stderr = os.Stderr
+
+	defer FixConsoleColor()
+
+	if code := RunCommand(cmd); code != 0 {
+		BeepFail()
+		return NewError(155, filepath.Base(path), code)
+	}
+
+	BeepSuccess()
+	return nil
+}
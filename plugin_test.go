@@ -0,0 +1,66 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindPlugin(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bit is not used on Windows")
+	}
+
+	AdminDir = t.TempDir()
+	os.MkdirAll(GetPluginDir(), os.ModePerm)
+
+	path := filepath.Join(GetPluginDir(), "cmapi-cli-odom")
+	os.WriteFile(path, []byte("#!/bin/sh\n"), 0644)
+	assert.Equal(t, "", FindPlugin("odom"))
+
+	os.Chmod(path, 0755)
+	assert.Equal(t, path, FindPlugin("odom"))
+
+	assert.Equal(t, "", FindPlugin("../odom"))
+	assert.Equal(t, "", FindPlugin("missing"))
+}
+
+func TestRunPluginCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("executable bit is not used on Windows")
+	}
+
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+	WorkingDir = wd
+	AdminDir = t.TempDir()
+	os.MkdirAll(GetPluginDir(), os.ModePerm)
+
+	path := filepath.Join(GetPluginDir(), "cmapi-cli-odom")
+	os.WriteFile(path, []byte("#!/bin/sh\n"), 0755)
+
+	MockCommandsQueue = []CommandSpec{
+		{path + " upload --fast", "", "", 0},
+		{path, "", "", 3},
+	}
+
+	assert.True(t, HandleCommand("odom", []string{"upload", "--fast"}))
+	assert.False(t, HandleCommand("odom", []string{}))
+	assert.Empty(t, MockCommandsQueue)
+
+	env := GetPluginEnv(wd, Secret)
+	assert.Contains(t, env, "CMAPI_CLI_PROJECT_ROOT="+wd)
+	assert.Contains(t, env, "CMAPI_CLI_ADMIN_DIR="+AdminDir)
+
+	password := Secret["password"]
+	Secret["password"] = "hunter2"
+	defer func() { Secret["password"] = password }()
+	assert.Contains(t, strings.Join(GetPluginEnv(wd, Secret), "\n"), "hunter2")
+	assert.NotContains(t, strings.Join(GetPluginEnv(wd, RedactSecrets(Secret)), "\n"), "hunter2")
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+)
+
+// ProjectConfig is the per-project setting stored in the project repository.
+type ProjectConfig struct {
+	Kernel            string            `json:"kernel,omitempty"`
+	Templates         map[string]string `json:"templates,omitempty"`
+	Variants          []BuildVariant    `json:"variants,omitempty"`
+	Hooks             map[string]string `json:"hooks,omitempty"`
+	Template          string            `json:"template,omitempty"`
+	TemplateCommit    string            `json:"template-commit,omitempty"`
+	TemplateVariables map[string]string `json:"template-variables,omitempty"`
+}
+
+// GetProjectConfigPath returns the path of the project config file.
+// No side effect
+func GetProjectConfigPath(projectRoot string) string {
+	return filepath.Join(projectRoot, ".cmapi", "config.json")
+}
+
+// ReadProjectConfig reads the project config file. An empty config is returned if the file does not exist.
+// Returns nil if the file exists but cannot be parsed.
+// No side effect
+func ReadProjectConfig(projectRoot string) *ProjectConfig {
+	config := &ProjectConfig{}
+
+	data, err := os.ReadFile(GetProjectConfigPath(projectRoot))
+	if os.IsNotExist(err) {
+		return config
+	} else if err != nil {
+		return nil
+	}
+
+	if json.Unmarshal(data, config) != nil {
+		return nil
+	}
+
+	return config
+}
+
+// WriteProjectConfig writes the project config file.
+func WriteProjectConfig(projectRoot string, config *ProjectConfig) error {
+	data, err := json.MarshalIndent(config, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	path := GetProjectConfigPath(projectRoot)
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		return err
+	}
+
+	return os.WriteFile(path, data, 0644)
+}
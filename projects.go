@@ -0,0 +1,223 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// ProjectSummary is the state of a local project in the workspace directory
+type ProjectSummary struct {
+	Label      string     `json:"label"`
+	Path       string     `json:"path"`
+	IsGitRepo  bool       `json:"git"`
+	Branch     string     `json:"branch,omitempty"`
+	Changes    int        `json:"uncommitted-changes"`
+	Ahead      int        `json:"ahead"`
+	Behind     int        `json:"behind"`
+	LastCommit *time.Time `json:"last-commit,omitempty"`
+	Kernel     string     `json:"kernel,omitempty"`
+	LastBuild  *time.Time `json:"last-build,omitempty"`
+}
+
+// ProjectScanConcurrency is the number of projects checked at the same time
+var ProjectScanConcurrency = 8
+
+// GetProjectLabel returns the label of the project from its directory name, without the repo slug prefix.
+// No side effect
+func GetProjectLabel(projectRoot string) string {
+	name := filepath.Base(projectRoot)
+	prefix := Secret["repo-slug-prefix"]
+	if prefix != "" && len(name) > len(prefix) && strings.EqualFold(name[:len(prefix)], prefix) {
+		return name[len(prefix):]
+	}
+	return name
+}
+
+// ParseBranchStatus returns the branch, the number of changes and the number of commits ahead of and behind
+// the upstream in the output of 'git status --porcelain=v2 --branch'.
+// No side effect
+func ParseBranchStatus(output string) (string, int, int, int) {
+	branch, changes, ahead, behind := "", 0, 0, 0
+	for _, line := range strings.Split(output, "\n") {
+		line = strings.TrimSpace(line)
+		switch {
+		case line == "":
+		case strings.HasPrefix(line, "# branch.head "):
+			branch = strings.TrimPrefix(line, "# branch.head ")
+		case strings.HasPrefix(line, "# branch.ab "):
+			fields := strings.Fields(strings.TrimPrefix(line, "# branch.ab "))
+			if len(fields) == 2 {
+				ahead, _ = strconv.Atoi(strings.TrimPrefix(fields[0], "+"))
+				behind, _ = strconv.Atoi(strings.TrimPrefix(fields[1], "-"))
+			}
+		case strings.HasPrefix(line, "#"):
+		default:
+			changes++
+		}
+	}
+	return branch, changes, ahead, behind
+}
+
+// GetLastSuccessfulBuild returns the time of the last successful run of the project in the history, or nil
+// if there is none.
+// No side effect
+func GetLastSuccessfulBuild(entries []HistoryEntry, projectRoot string) *time.Time {
+	for i := len(entries) - 1; i >= 0; i-- {
+		if entries[i].Success && filepath.Clean(entries[i].Project) == filepath.Clean(projectRoot) {
+			return &entries[i].Timestamp
+		}
+	}
+	return nil
+}
+
+// GetProjectSummary returns the state of the project. Returns false if the directory is neither a PROS
+// project nor a Git repository.
+func GetProjectSummary(projectRoot string, history []HistoryEntry) (ProjectSummary, bool) {
+	// Only the root of a repository counts, not a directory inside a repository
+	_, err := os.Stat(filepath.Join(projectRoot, ".git"))
+	isPros := IsProsProject(projectRoot)
+	isGit := err == nil && IsGitRepo(projectRoot)
+	if !isPros && !isGit {
+		return ProjectSummary{}, false
+	}
+
+	summary := ProjectSummary{Label: GetProjectLabel(projectRoot), Path: projectRoot, IsGitRepo: isGit}
+
+	if isGit {
+		status, _, _ := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain=v2", "--branch")
+		summary.Branch, summary.Changes, summary.Ahead, summary.Behind = ParseBranchStatus(status)
+
+		date, _, code := RunCommandGetOutput(projectRoot, "git", "log", "-1", "--format=%cI")
+		if t, err := time.Parse(time.RFC3339, strings.TrimSpace(date)); code == 0 && err == nil {
+			summary.LastCommit = &t
+		}
+	}
+
+	if isPros {
+		summary.Kernel = GetInstalledKernel(projectRoot)
+	}
+	summary.LastBuild = GetLastSuccessfulBuild(history, projectRoot)
+
+	return summary, true
+}
+
+// ForEachConcurrently calls the function with 0 to count-1 in order, with at most n calls running at the
+// same time. Returns after all calls return.
+func ForEachConcurrently(n int, count int, fn func(i int)) {
+	jobs := make(chan int)
+
+	var wg sync.WaitGroup
+	for worker := 0; worker < n; worker++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				fn(i)
+			}
+		}()
+	}
+
+	for i := 0; i < count; i++ {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// ScanWorkspace returns the state of all projects in the workspace directory, sorted by directory name.
+// The projects are checked concurrently.
+func ScanWorkspace(workspaceDir string) ([]ProjectSummary, error) {
+	entries, err := os.ReadDir(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+	history := ReadHistory(GetHistoryFilePath())
+
+	results := make([]ProjectSummary, len(entries))
+	found := make([]bool, len(entries))
+	ForEachConcurrently(ProjectScanConcurrency, len(entries), func(i int) {
+		if entries[i].IsDir() && !strings.HasPrefix(entries[i].Name(), ".") {
+			results[i], found[i] = GetProjectSummary(filepath.Join(workspaceDir, entries[i].Name()), history)
+		}
+	})
+
+	rtn := []ProjectSummary{}
+	for i := range results {
+		if found[i] {
+			rtn = append(rtn, results[i])
+		}
+	}
+	return rtn, nil
+}
+
+// FormatTime returns the local time in minutes, or "-" if it is nil.
+// No side effect
+func FormatTime(t *time.Time) string {
+	if t == nil {
+		return "-"
+	}
+	return t.Local().Format("2006-01-02 15:04")
+}
+
+// GetProjectState returns the uncommitted changes and the commits ahead of and behind the upstream in short.
+// No side effect
+func GetProjectState(summary ProjectSummary) string {
+	if !summary.IsGitRepo {
+		return T("state-no-git")
+	}
+
+	parts := []string{}
+	if summary.Changes != 0 {
+		parts = append(parts, T("state-changed", summary.Changes))
+	}
+	if summary.Ahead != 0 {
+		parts = append(parts, fmt.Sprintf("+%d", summary.Ahead))
+	}
+	if summary.Behind != 0 {
+		parts = append(parts, fmt.Sprintf("-%d", summary.Behind))
+	}
+	if len(parts) == 0 {
+		return T("state-clean")
+	}
+	return strings.Join(parts, " ")
+}
+
+// ProjectsCommand lists the projects in the workspace directory with their branch, state, last commit,
+// kernel and last successful build
+func ProjectsCommand(workspaceDir string) error {
+	projects, err := ScanWorkspace(workspaceDir)
+	if err != nil {
+		return WrapError(err, 168, workspaceDir)
+	}
+
+	ReportData("workspace", workspaceDir)
+	ReportData("projects", projects)
+	if len(projects) == 0 {
+		return Success("projects-empty", workspaceDir)
+	}
+
+	format := "%-20s %-16s %-18s %-17s %-8s %s"
+	fmt.Fprintln(Console, HeaderText(fmt.Sprintf(format, T("projects-label"), T("projects-branch"),
+		T("projects-state"), T("projects-last-commit"), T("projects-kernel"), T("projects-last-build"))))
+	for _, project := range projects {
+		kernel := project.Kernel
+		if kernel == "" {
+			kernel = "-"
+		}
+		line := fmt.Sprintf(format, project.Label, project.Branch, GetProjectState(project),
+			FormatTime(project.LastCommit), kernel, FormatTime(project.LastBuild))
+
+		if project.Changes != 0 || project.Ahead != 0 || project.Behind != 0 {
+			fmt.Fprintln(Console, WarningText(line))
+		} else {
+			fmt.Fprintln(Console, line)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetProjectLabel(t *testing.T) {
+	assert.Equal(t, "WORLDS-2025", GetProjectLabel("/home/u/cmapi-projects/7984-WORLDS-2025"))
+	assert.Equal(t, "worlds", GetProjectLabel("7984-worlds"))
+	assert.Equal(t, "notes", GetProjectLabel("notes"))
+	assert.Equal(t, "7984-", GetProjectLabel("7984-"))
+}
+
+func TestParseBranchStatus(t *testing.T) {
+	output := "# branch.oid 1234567\n# branch.head master\n# branch.upstream origin/master\n# branch.ab +2 -1\n" +
+		"1 .M N... 100644 100644 100644 1234 1234 src/main.cpp\n? include/new.h\n"
+	branch, changes, ahead, behind := ParseBranchStatus(output)
+	assert.Equal(t, "master", branch)
+	assert.Equal(t, 2, changes)
+	assert.Equal(t, 2, ahead)
+	assert.Equal(t, 1, behind)
+
+	branch, changes, ahead, behind = ParseBranchStatus("# branch.oid (initial)\n# branch.head dev\n")
+	assert.Equal(t, "dev", branch)
+	assert.Equal(t, 0, changes+ahead+behind)
+}
+
+func TestGetLastSuccessfulBuild(t *testing.T) {
+	first := time.Date(2025, 1, 1, 0, 0, 0, 0, time.UTC)
+	second := first.Add(time.Hour)
+	entries := []HistoryEntry{
+		{Timestamp: first, Project: "/w/7984-A", Success: true},
+		{Timestamp: second, Project: "/w/7984-A/", Success: true},
+		{Timestamp: second.Add(time.Hour), Project: "/w/7984-A", Success: false},
+		{Timestamp: second, Project: "/w/7984-B", Success: false},
+	}
+	assert.Equal(t, &second, GetLastSuccessfulBuild(entries, "/w/7984-A"))
+	assert.Nil(t, GetLastSuccessfulBuild(entries, "/w/7984-B"))
+}
+
+func TestProjectsCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	AdminDir = t.TempDir()
+	defer func() { AdminDir = "" }()
+
+	workspace := t.TempDir()
+	projectA := filepath.Join(workspace, "7984-A")
+	os.MkdirAll(filepath.Join(projectA, ".git"), os.ModePerm)
+	os.WriteFile(filepath.Join(projectA, "project.pros"), []byte(testProjectPros), 0644)
+	os.MkdirAll(filepath.Join(workspace, "7984-B"), os.ModePerm)
+	os.WriteFile(filepath.Join(workspace, "7984-B", "project.pros"), []byte(testProjectPros), 0644)
+	os.MkdirAll(filepath.Join(workspace, "notes"), os.ModePerm)
+	os.WriteFile(filepath.Join(workspace, "readme.txt"), []byte{}, 0644)
+
+	built := time.Date(2025, 2, 1, 12, 0, 0, 0, time.UTC)
+	AppendHistory(GetHistoryFilePath(), HistoryEntry{Timestamp: built, Project: projectA, Success: true})
+
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head master\n# branch.ab +0 -3\n? a.txt\n", "", 0},
+		{"git log -1 --format=%cI", "2025-01-31T10:00:00+08:00\n", "", 0},
+	}
+
+	projects, err := ScanWorkspace(workspace)
+	assert.Nil(t, err)
+	assert.Equal(t, 2, len(projects))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+
+	a := projects[0]
+	assert.Equal(t, "A", a.Label)
+	assert.True(t, a.IsGitRepo)
+	assert.Equal(t, "master", a.Branch)
+	assert.Equal(t, 1, a.Changes)
+	assert.Equal(t, 3, a.Behind)
+	assert.Equal(t, "1 changed -3", GetProjectState(a))
+	assert.Equal(t, "2025-01-31T02:00:00Z", a.LastCommit.UTC().Format(time.RFC3339))
+	assert.Equal(t, "3.8.0", a.Kernel)
+	assert.True(t, built.Equal(*a.LastBuild))
+
+	b := projects[1]
+	assert.Equal(t, "B", b.Label)
+	assert.False(t, b.IsGitRepo)
+	assert.Equal(t, "no git", GetProjectState(b))
+
+	Language = "zh-Hant"
+	assert.Equal(t, "1 個變更 -3", GetProjectState(a))
+	assert.Equal(t, "沒有 Git", GetProjectState(b))
+	assert.Equal(t, "無變更", GetProjectState(ProjectSummary{IsGitRepo: true}))
+	Language = "en"
+	assert.Nil(t, b.LastCommit)
+	assert.Nil(t, b.LastBuild)
+
+	assert.Nil(t, ProjectsCommand(t.TempDir()))
+	assert.Equal(t, 168, GetErrorCode(ProjectsCommand(filepath.Join(workspace, "missing"))))
+}
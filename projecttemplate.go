@@ -0,0 +1,207 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TemplateManifest declares the variables of a template repository, in TemplateManifestName at its root
+type TemplateManifest struct {
+	Variables []TemplateVariable `json:"variables"`
+}
+
+// TemplateVariable is a variable the user is asked for when a project is created from the template
+type TemplateVariable struct {
+	Name    string `json:"name"`
+	Prompt  string `json:"prompt,omitempty"`
+	Default string `json:"default,omitempty"`
+}
+
+// TemplateManifestName is the name of the manifest file in the template, which is not copied to the project
+const TemplateManifestName = "cmapi-template.json"
+
+// TemplatePlaceholder matches a placeholder like {{label}} in the file contents and paths of a template
+var TemplatePlaceholder = regexp.MustCompile(`{{\s*([A-Za-z_][A-Za-z0-9_]*)\s*}}`)
+
+// AskTemplateVariable returns the value the user enters for the variable, or the default value if nothing is
+// entered or the input is not a terminal
+var AskTemplateVariable = func(prompt string, defaultValue string) string {
+	if !IsTerminal(os.Stdin) {
+		return defaultValue
+	}
+
+	fmt.Fprint(Console, InfoText(prompt)+" ["+defaultValue+"]: ")
+	value, _ := bufio.NewReader(os.Stdin).ReadString('\n')
+	if value = strings.TrimSpace(value); value == "" {
+		return defaultValue
+	}
+	return value
+}
+
+// GetTemplateSources returns the template repository slugs and local template directories, the default
+// template repository first.
+// No side effect
+func GetTemplateSources() []string {
+	rtn := []string{Secret["template-repo"]}
+	for _, source := range strings.Split(Secret["templates"], ",") {
+		if source = strings.TrimSpace(source); source != "" {
+			rtn = append(rtn, source)
+		}
+	}
+	return rtn
+}
+
+// IsLocalTemplate returns true if the template source is a local directory instead of a repository slug.
+// No side effect
+func IsLocalTemplate(source string) bool {
+	return strings.HasPrefix(source, "~") || strings.HasPrefix(source, ".") || strings.ContainsAny(source, `/\`)
+}
+
+// GetTemplateName returns the name of the template source used with --template, the base name of the
+// directory or the repository slug.
+// No side effect
+func GetTemplateName(source string) string {
+	return filepath.Base(filepath.FromSlash(source))
+}
+
+// FindTemplateSource returns the template source with the name, case-insensitive. The default template
+// repository is returned if the name is empty.
+// No side effect
+func FindTemplateSource(name string) (string, bool) {
+	sources := GetTemplateSources()
+	if name == "" {
+		return sources[0], true
+	}
+
+	for _, source := range sources {
+		if strings.EqualFold(GetTemplateName(source), name) {
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// PrepareTemplate returns the root of the template with the name. A template repository is cloned or pulled
+// in the administrator directory first unless noPull is true.
+func PrepareTemplate(name string, noPull bool) (string, error) {
+	source, ok := FindTemplateSource(name)
+	if !ok {
+		return "", NewError(179, name)
+	}
+	if !IsLocalTemplate(source) {
+		return UpdateTemplateRepo(strings.ToLower(source), noPull)
+	}
+
+	templateRoot := source
+	if home, err := os.UserHomeDir(); err == nil && strings.HasPrefix(templateRoot, "~") {
+		templateRoot = filepath.Join(home, templateRoot[1:])
+	}
+	if info, err := os.Stat(templateRoot); err != nil || !info.IsDir() {
+		return "", NewError(117, templateRoot)
+	}
+	return templateRoot, nil
+}
+
+// ReadTemplateManifest reads the manifest of the template. Returns nil if the template has no manifest.
+// No side effect
+func ReadTemplateManifest(templateRoot string) (*TemplateManifest, error) {
+	data, err := os.ReadFile(filepath.Join(templateRoot, TemplateManifestName))
+	if os.IsNotExist(err) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	manifest := &TemplateManifest{}
+	if err := json.Unmarshal(data, manifest); err != nil {
+		return nil, err
+	}
+	return manifest, nil
+}
+
+// SubstituteTemplateVariables replaces the placeholders of the variables in the text. Unknown placeholders
+// are kept as they are.
+// No side effect
+func SubstituteTemplateVariables(text string, variables map[string]string) string {
+	return TemplatePlaceholder.ReplaceAllStringFunc(text, func(placeholder string) string {
+		if value, ok := variables[TemplatePlaceholder.FindStringSubmatch(placeholder)[1]]; ok {
+			return value
+		}
+		return placeholder
+	})
+}
+
+// GetTemplateVariables returns the values of the built-in variables and the variables declared in the
+// manifest of the template. The user is asked for each declared variable.
+func GetTemplateVariables(templateRoot string, label string, projectName string) (map[string]string, error) {
+	variables := map[string]string{
+		"label":        label,
+		"team":         strings.Trim(Secret["repo-slug-prefix"], "- "),
+		"project_name": projectName,
+		"year":         strconv.Itoa(time.Now().Year()),
+	}
+
+	manifest, err := ReadTemplateManifest(templateRoot)
+	if err != nil {
+		return nil, WrapError(err, 180, filepath.Join(templateRoot, TemplateManifestName))
+	}
+	if manifest == nil {
+		return variables, nil
+	}
+
+	for _, variable := range manifest.Variables {
+		defaultValue, ok := variables[variable.Name]
+		if !ok {
+			defaultValue = SubstituteTemplateVariables(variable.Default, variables)
+		}
+		prompt := variable.Prompt
+		if prompt == "" {
+			prompt = variable.Name
+		}
+		variables[variable.Name] = AskTemplateVariable(prompt, defaultValue)
+	}
+	return variables, nil
+}
+
+// SubstituteTemplateFiles replaces the placeholders in the contents and the names of the files copied from
+// the template. The files must be in the order of the copy, parents before children. Binary files keep their
+// contents.
+func SubstituteTemplateFiles(files []string, variables map[string]string) error {
+	for _, file := range files {
+		info, err := os.Lstat(file)
+		if err != nil || !info.Mode().IsRegular() {
+			continue
+		}
+
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if bytes.IndexByte(data, 0) != -1 || !TemplatePlaceholder.Match(data) {
+			continue
+		}
+		if err := os.WriteFile(file, []byte(SubstituteTemplateVariables(string(data), variables)), info.Mode().Perm()); err != nil {
+			return err
+		}
+	}
+
+	// Rename the children before their parents so that the paths stay valid
+	for i := len(files) - 1; i >= 0; i-- {
+		name := filepath.Base(files[i])
+		if newName := SubstituteTemplateVariables(name, variables); newName != name {
+			if err := os.Rename(files[i], filepath.Join(filepath.Dir(files[i]), newName)); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
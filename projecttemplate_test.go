@@ -0,0 +1,115 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestFindTemplateSource(t *testing.T) {
+	Secret["templates"] = "lemlib-template, ~/templates/Skills,"
+	defer func() { Secret["templates"] = "" }()
+
+	assert.Equal(t, []string{"cmapi-build", "lemlib-template", "~/templates/Skills"}, GetTemplateSources())
+
+	source, ok := FindTemplateSource("")
+	assert.True(t, ok)
+	assert.Equal(t, "cmapi-build", source)
+	source, _ = FindTemplateSource("LemLib-Template")
+	assert.Equal(t, "lemlib-template", source)
+	source, _ = FindTemplateSource("skills")
+	assert.Equal(t, "~/templates/Skills", source)
+	_, ok = FindTemplateSource("missing")
+	assert.False(t, ok)
+
+	assert.False(t, IsLocalTemplate("lemlib-template"))
+	assert.True(t, IsLocalTemplate("~/templates/Skills"))
+	assert.True(t, IsLocalTemplate("./skills"))
+	assert.True(t, IsLocalTemplate(`C:\templates\skills`))
+}
+
+func TestPrepareTemplate(t *testing.T) {
+	dir := t.TempDir()
+	Secret["templates"] = dir + "," + filepath.Join(dir, "missing")
+	defer func() { Secret["templates"] = "" }()
+
+	root, err := PrepareTemplate(filepath.Base(dir), false)
+	assert.Nil(t, err)
+	assert.Equal(t, dir, root)
+
+	_, err = PrepareTemplate("missing", false)
+	assert.Equal(t, 117, GetErrorCode(err))
+	_, err = PrepareTemplate("unknown", false)
+	assert.Equal(t, 179, GetErrorCode(err))
+}
+
+func TestSubstituteTemplateVariables(t *testing.T) {
+	variables := map[string]string{"label": "A", "team": "7984"}
+	assert.Equal(t, "7984-A {{ unknown }}", SubstituteTemplateVariables("{{team}}-{{ label }} {{ unknown }}", variables))
+	assert.Equal(t, "no placeholder", SubstituteTemplateVariables("no placeholder", variables))
+}
+
+func TestGetTemplateVariables(t *testing.T) {
+	templateRoot := t.TempDir()
+	year := strconv.Itoa(time.Now().Year())
+
+	variables, err := GetTemplateVariables(templateRoot, "A", "7984-A")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"label": "A", "team": "7984", "project_name": "7984-A", "year": year}, variables)
+
+	original := AskTemplateVariable
+	defer func() { AskTemplateVariable = original }()
+	prompts := []string{}
+	AskTemplateVariable = func(prompt string, defaultValue string) string {
+		prompts = append(prompts, prompt+"="+defaultValue)
+		if prompt == "Robot name" {
+			return "Bumblebee"
+		}
+		return defaultValue
+	}
+
+	os.WriteFile(filepath.Join(templateRoot, TemplateManifestName), []byte(`{"variables": [
+		{"name": "robot", "prompt": "Robot name", "default": "{{label}} bot"},
+		{"name": "author", "default": "Team {{team}}"},
+		{"name": "team", "prompt": "Team number"}
+	]}`), 0644)
+	variables, err = GetTemplateVariables(templateRoot, "A", "7984-A")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"Robot name=A bot", "author=Team 7984", "Team number=7984"}, prompts)
+	assert.Equal(t, "Bumblebee", variables["robot"])
+	assert.Equal(t, "Team 7984", variables["author"])
+
+	os.WriteFile(filepath.Join(templateRoot, TemplateManifestName), []byte("invalid"), 0644)
+	_, err = GetTemplateVariables(templateRoot, "A", "7984-A")
+	assert.Equal(t, 180, GetErrorCode(err))
+}
+
+func TestSubstituteTemplateFiles(t *testing.T) {
+	root := t.TempDir()
+	files := []string{
+		filepath.Join(root, "{{label}}"),
+		filepath.Join(root, "{{label}}", "{{project_name}}.txt"),
+		filepath.Join(root, "{{label}}", "logo.bin"),
+		filepath.Join(root, "README.md"),
+	}
+	os.MkdirAll(files[0], os.ModePerm)
+	os.WriteFile(files[1], []byte("Project {{project_name}} of team {{team}}\n"), 0644)
+	os.WriteFile(files[2], []byte("\x00{{label}}"), 0644)
+	os.WriteFile(files[3], []byte("# {{label}}\n"), 0644)
+
+	variables := map[string]string{"label": "A", "project_name": "7984-A", "team": "7984"}
+	assert.Nil(t, SubstituteTemplateFiles(files, variables))
+
+	data, _ := os.ReadFile(filepath.Join(root, "A", "7984-A.txt"))
+	assert.Equal(t, "Project 7984-A of team 7984\n", string(data))
+	data, _ = os.ReadFile(filepath.Join(root, "A", "logo.bin"))
+	assert.Equal(t, "\x00{{label}}", string(data))
+	data, _ = os.ReadFile(filepath.Join(root, "README.md"))
+	assert.Equal(t, "# A\n", string(data))
+	_, err := os.Stat(filepath.Join(root, "{{label}}"))
+	assert.True(t, os.IsNotExist(err))
+}
@@ -0,0 +1,239 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+)
+
+const (
+	ProsProjectObject  = "pros.conductor.project.Project"
+	ProsTemplateObject = "pros.conductor.templates.local_template.LocalTemplate"
+)
+
+// ProsProject is the model of the project.pros file.
+// Fields which are not modelled are kept in Extra so that the file round-trips without losing anything.
+type ProsProject struct {
+	ProjectName   string
+	Target        string
+	Templates     map[string]*ProsTemplate
+	UploadOptions map[string]any
+	Extra         map[string]json.RawMessage
+}
+
+// ProsTemplate is the model of a template installed in a PROS project.
+type ProsTemplate struct {
+	Object  string
+	Name    string
+	Version string
+	Target  string
+	Extra   map[string]json.RawMessage
+}
+
+// pyObject is the jsonpickle envelope used by the PROS CLI.
+type pyObject struct {
+	Object string          `json:"py/object"`
+	State  json.RawMessage `json:"py/state"`
+}
+
+// NewProsProject returns an empty V5 project with the given name.
+// No side effect
+func NewProsProject(projectName string) *ProsProject {
+	return &ProsProject{
+		ProjectName:   projectName,
+		Target:        "v5",
+		Templates:     map[string]*ProsTemplate{},
+		UploadOptions: map[string]any{},
+		Extra:         map[string]json.RawMessage{},
+	}
+}
+
+// GetProsProjectPath returns the path of the project.pros file.
+// No side effect
+func GetProsProjectPath(projectRoot string) string {
+	return filepath.Join(projectRoot, "project.pros")
+}
+
+// ReadProsProject reads and validates the project.pros file.
+// No side effect
+func ReadProsProject(projectRoot string) (*ProsProject, error) {
+	data, err := os.ReadFile(GetProsProjectPath(projectRoot))
+	if err != nil {
+		return nil, err
+	}
+
+	project := &ProsProject{}
+	if err := json.Unmarshal(data, project); err != nil {
+		return nil, err
+	}
+
+	if err := project.Validate(); err != nil {
+		return nil, err
+	}
+
+	return project, nil
+}
+
+// WriteProsProject validates and writes the project.pros file.
+func WriteProsProject(projectRoot string, project *ProsProject) error {
+	if err := project.Validate(); err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(project, "", "    ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(GetProsProjectPath(projectRoot), data, 0644)
+}
+
+// Validate returns an error if the project is not a valid PROS project.
+// No side effect
+func (p *ProsProject) Validate() error {
+	if p.ProjectName == "" {
+		return errors.New("missing project name")
+	}
+
+	if p.Target != "v5" && p.Target != "cortex" {
+		return fmt.Errorf("unknown target '%s'", p.Target)
+	}
+
+	for key, template := range p.Templates {
+		if template == nil {
+			return fmt.Errorf("template '%s' is empty", key)
+		}
+		if template.Name != key {
+			return fmt.Errorf("template '%s' has a mismatched name '%s'", key, template.Name)
+		}
+		if template.Version == "" {
+			return fmt.Errorf("template '%s' has no version", key)
+		}
+	}
+
+	return nil
+}
+
+func (p *ProsProject) UnmarshalJSON(data []byte) error {
+	var envelope pyObject
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	if envelope.Object != ProsProjectObject {
+		return fmt.Errorf("unknown object '%s'", envelope.Object)
+	}
+
+	state := map[string]json.RawMessage{}
+	if err := json.Unmarshal(envelope.State, &state); err != nil {
+		return err
+	}
+
+	p.Templates = map[string]*ProsTemplate{}
+	p.UploadOptions = map[string]any{}
+	fields := map[string]any{
+		"project_name":   &p.ProjectName,
+		"target":         &p.Target,
+		"templates":      &p.Templates,
+		"upload_options": &p.UploadOptions,
+	}
+	if err := takeFields(state, fields); err != nil {
+		return err
+	}
+	if p.Templates == nil {
+		p.Templates = map[string]*ProsTemplate{}
+	}
+	if p.UploadOptions == nil {
+		p.UploadOptions = map[string]any{}
+	}
+	p.Extra = state
+
+	return nil
+}
+
+func (p *ProsProject) MarshalJSON() ([]byte, error) {
+	fields := map[string]any{
+		"project_name":   p.ProjectName,
+		"target":         p.Target,
+		"templates":      p.Templates,
+		"upload_options": p.UploadOptions,
+	}
+	return marshalPyObject(ProsProjectObject, p.Extra, fields)
+}
+
+func (t *ProsTemplate) UnmarshalJSON(data []byte) error {
+	var envelope pyObject
+	if err := json.Unmarshal(data, &envelope); err != nil {
+		return err
+	}
+	t.Object = envelope.Object
+
+	state := map[string]json.RawMessage{}
+	if err := json.Unmarshal(envelope.State, &state); err != nil {
+		return err
+	}
+
+	fields := map[string]any{
+		"name":    &t.Name,
+		"version": &t.Version,
+		"target":  &t.Target,
+	}
+	if err := takeFields(state, fields); err != nil {
+		return err
+	}
+	t.Extra = state
+
+	return nil
+}
+
+func (t *ProsTemplate) MarshalJSON() ([]byte, error) {
+	object := t.Object
+	if object == "" {
+		object = ProsTemplateObject
+	}
+
+	fields := map[string]any{
+		"name":    t.Name,
+		"version": t.Version,
+	}
+	if t.Target != "" {
+		fields["target"] = t.Target
+	}
+	return marshalPyObject(object, t.Extra, fields)
+}
+
+// takeFields decodes the known fields and removes them from the state, leaving only the unknown fields.
+func takeFields(state map[string]json.RawMessage, fields map[string]any) error {
+	for key, ptr := range fields {
+		if raw, ok := state[key]; ok {
+			if err := json.Unmarshal(raw, ptr); err != nil {
+				return fmt.Errorf("invalid field '%s': %w", key, err)
+			}
+			delete(state, key)
+		}
+	}
+	return nil
+}
+
+// marshalPyObject encodes the known fields and the unknown fields in a jsonpickle envelope.
+func marshalPyObject(object string, extra map[string]json.RawMessage, fields map[string]any) ([]byte, error) {
+	state := map[string]json.RawMessage{}
+	for key, value := range extra {
+		state[key] = value
+	}
+	for key, value := range fields {
+		raw, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		state[key] = raw
+	}
+
+	rawState, err := json.Marshal(state)
+	if err != nil {
+		return nil, err
+	}
+
+	return json.Marshal(pyObject{Object: object, State: rawState})
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProsProjectRoundTrip(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	original := `{
+	"py/object": "pros.conductor.project.Project",
+	"py/state": {
+		"project_name": "7984-TEST",
+		"target": "v5",
+		"templates": {
+			"kernel": {
+				"py/object": "pros.conductor.templates.local_template.LocalTemplate",
+				"py/state": {"name": "kernel", "version": "3.8.0", "target": "v5", "system_files": ["include/api.h"]}
+			}
+		},
+		"upload_options": {"slot": 2},
+		"use_early_access": false
+	}
+}`
+	os.WriteFile("project.pros", []byte(original), 0644)
+
+	project, err := ReadProsProject(wd)
+	assert.Nil(t, err)
+	assert.Equal(t, "7984-TEST", project.ProjectName)
+	assert.Equal(t, "v5", project.Target)
+	assert.Equal(t, "3.8.0", project.Templates["kernel"].Version)
+	assert.Equal(t, float64(2), project.UploadOptions["slot"])
+
+	assert.Nil(t, WriteProsProject(wd, project))
+
+	var expected, actual any
+	json.Unmarshal([]byte(original), &expected)
+	data, _ := os.ReadFile("project.pros")
+	json.Unmarshal(data, &actual)
+	assert.Equal(t, expected, actual)
+}
+
+func TestProsProjectEscaping(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	assert.Nil(t, WriteProsProject(wd, NewProsProject(`a "quoted" \ name`)))
+
+	project, err := ReadProsProject(wd)
+	assert.Nil(t, err)
+	assert.Equal(t, `a "quoted" \ name`, project.ProjectName)
+	assert.Empty(t, project.Templates)
+}
+
+func TestProsProjectValidate(t *testing.T) {
+	assert.Nil(t, NewProsProject("test").Validate())
+	assert.NotNil(t, NewProsProject("").Validate())
+
+	project := NewProsProject("test")
+	project.Target = "v6"
+	assert.NotNil(t, project.Validate())
+
+	project = NewProsProject("test")
+	project.Templates["kernel"] = &ProsTemplate{Name: "okapilib", Version: "4.8.0"}
+	assert.NotNil(t, project.Validate())
+
+	project.Templates["kernel"] = &ProsTemplate{Name: "kernel"}
+	assert.NotNil(t, project.Validate())
+
+	project.Templates["kernel"] = nil
+	assert.Equal(t, "template 'kernel' is empty", project.Validate().Error())
+
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte("any"), 0644)
+	_, err := ReadProsProject(wd)
+	assert.NotNil(t, err)
+
+	os.WriteFile("project.pros", []byte(`{
+	"py/object": "pros.conductor.project.Project",
+	"py/state": {"project_name": "7984-TEST", "target": "v5", "templates": {"okapi": null}}
+}`), 0644)
+	_, err = ReadProsProject(wd)
+	assert.Equal(t, "template 'okapi' is empty", err.Error())
+}
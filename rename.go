@@ -0,0 +1,226 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// UpdateRemoteRepo updates the fields of the repository on the BitBucket server, and returns the slug of the
+// repository after the update
+func UpdateRemoteRepo(repoSlug string, fields map[string]any) (string, error) {
+	payload, err := json.Marshal(fields)
+	if err != nil {
+		return "", err
+	}
+
+	url := BitbucketApiUrl + "/repositories/" + Secret["workspace"] + "/" + repoSlug
+	req, err := NewBitbucketRequest("PUT", url, bytes.NewReader(payload))
+	if err != nil {
+		return "", err
+	}
+
+	res, err := (&http.Client{}).Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer res.Body.Close()
+
+	var repo struct {
+		Slug string `json:"slug"`
+	}
+	err = json.NewDecoder(res.Body).Decode(&repo)
+	if res.StatusCode != http.StatusOK {
+		return "", errors.New("unexpected status " + res.Status)
+	}
+	if err != nil {
+		return "", err
+	}
+	if repo.Slug == "" {
+		return repoSlug, nil
+	}
+	return repo.Slug, nil
+}
+
+// GetUnpushedChanges returns the number of uncommitted changes, the number of stashes and the number of
+// commits on any local branch which are not pushed to the server. Returns an error if Git fails.
+// No side effect
+func GetUnpushedChanges(projectRoot string) (int, int, int, error) {
+	counts := []int{}
+	for _, args := range [][]string{
+		{"status", "--porcelain"},
+		{"stash", "list"},
+		{"log", "--branches", "--not", "--remotes", "--format=%h"},
+	} {
+		out, _, code := RunCommandGetOutput(projectRoot, "git", args...)
+		if err := GetExitError(code, "git", args...); err != nil {
+			return 0, 0, 0, err
+		}
+
+		count := 0
+		for _, line := range strings.Split(out, "\n") {
+			if strings.TrimSpace(line) != "" {
+				count++
+			}
+		}
+		counts = append(counts, count)
+	}
+	return counts[0], counts[1], counts[2], nil
+}
+
+// LeaveProject changes to the workspace directory if the active project is in the project root, which is
+// about to be moved or removed. Returns the directory it left, or an empty string if it did not
+func LeaveProject(projectRoot string, workspaceDir string) string {
+	if rel, err := filepath.Rel(projectRoot, WorkingDir); err == nil && !strings.HasPrefix(rel, "..") {
+		left := WorkingDir
+		if os.Chdir(workspaceDir) == nil {
+			WorkingDir = workspaceDir
+			return left
+		}
+	}
+	return ""
+}
+
+// RenameCommand moves the local project to the directory of the new label, renames the repository of the
+// project on the server, links the project to the renamed repository and commits the new project name. The
+// local project is moved back if the repository cannot be renamed.
+func RenameCommand(oldLabel string, newLabel string, workspaceDir string) error {
+	if !IsValidLabel(oldLabel) || !IsValidLabel(newLabel) {
+		return NewError(200)
+	}
+
+	oldRoot := FindProjectByLabel(oldLabel, workspaceDir)
+	oldSlug := strings.ToLower(Secret["repo-slug-prefix"] + oldLabel)
+	if oldRoot != "" {
+		oldSlug = strings.ToLower(filepath.Base(oldRoot))
+	}
+	ReportData("old-label", oldLabel)
+	ReportData("old-repo-slug", oldSlug)
+	fmt.Fprintln(Console, InfoText(T("resolved-old-label"))+oldLabel+" ("+Secret["workspace"]+"/"+oldSlug+")")
+	PrintResolvedLabel(newLabel, workspaceDir)
+
+	newRoot := filepath.Join(workspaceDir, Secret["repo-slug-prefix"]+newLabel)
+	left := ""
+	// The active project is back where it was if the local project is moved back
+	returnToProject := func() {
+		if left != "" && os.Chdir(left) == nil {
+			WorkingDir = left
+		}
+	}
+	if oldRoot != "" {
+		if !IsGitRepo(oldRoot) {
+			return NewError(102)
+		}
+		if changes, _, _, err := GetUnpushedChanges(oldRoot); err != nil {
+			return WrapError(err, 190, oldRoot)
+		} else if changes != 0 {
+			return NewError(173)
+		}
+
+		if _, err := os.Stat(newRoot); err == nil {
+			return NewError(181, newRoot)
+		}
+		left = LeaveProject(oldRoot, workspaceDir)
+		if err := os.Rename(oldRoot, newRoot); err != nil {
+			returnToProject()
+			return WrapError(err, 183, oldRoot, newRoot)
+		}
+	}
+
+	newSlug, err := UpdateRemoteRepo(oldSlug, map[string]any{"name": Secret["repo-name-prefix"] + newLabel})
+	if err != nil {
+		if oldRoot != "" && os.Rename(newRoot, oldRoot) == nil {
+			returnToProject()
+		}
+		return WrapError(err, 182, Secret["workspace"]+"/"+oldSlug)
+	}
+	ResetRemoteLabels()
+	ReportData("slug", newSlug)
+
+	if oldRoot == "" {
+		return Success("renamed-remote", Secret["workspace"]+"/"+oldSlug, Secret["workspace"]+"/"+newSlug)
+	}
+	ReportData("project", newRoot)
+
+	// The repository is renamed on the server, so the local project is not moved back from here, and it is
+	// the active project again if it was
+	if left != "" {
+		if err := ChangeProject(newRoot); err != nil {
+			return WrapError(err, 191, Secret["workspace"]+"/"+newSlug, newRoot)
+		}
+	}
+	notUpdated := func(err error) error {
+		return WrapError(err, 191, Secret["workspace"]+"/"+newSlug, newRoot)
+	}
+	if err := LinkLocalRepoToServerCommand(newRoot, newSlug); err != nil {
+		return notUpdated(err)
+	}
+
+	if IsProsProject(newRoot) {
+		project, err := ReadProsProject(newRoot)
+		if err != nil {
+			return notUpdated(NewError(153, err))
+		}
+		project.ProjectName = filepath.Base(newRoot)
+		if err := WriteProsProject(newRoot, project); err != nil {
+			return notUpdated(WrapError(err, 124))
+		}
+	}
+
+	if err := RunCommandsGetError(newRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "--allow-empty", "-m", "Rename " + oldLabel + " to " + newLabel}); err != nil {
+		return notUpdated(WrapError(err, 184))
+	}
+
+	return Success("renamed", oldRoot, newRoot)
+}
+
+// ArchiveCommand moves the repository of the project to the archive project on the server and removes the
+// local project. Nothing is changed if the local project has changes which are not pushed to the server, or
+// if Git cannot tell. Bitbucket has no read-only switch for a repository, so the permissions of the archive
+// project decide who can still write to it.
+func ArchiveCommand(label string, workspaceDir string) error {
+	if !IsValidLabel(label) {
+		return NewError(200)
+	}
+	PrintResolvedLabel(label, workspaceDir)
+
+	projectRoot := FindProjectByLabel(label, workspaceDir)
+	repoSlug := strings.ToLower(Secret["repo-slug-prefix"] + label)
+	if projectRoot != "" {
+		repoSlug = strings.ToLower(filepath.Base(projectRoot))
+		if !IsGitRepo(projectRoot) {
+			return NewError(102)
+		}
+		changes, stashes, commits, err := GetUnpushedChanges(projectRoot)
+		if err != nil {
+			return WrapError(err, 190, projectRoot)
+		}
+		if changes != 0 || stashes != 0 || commits != 0 {
+			return NewError(185, projectRoot, changes, stashes, commits)
+		}
+	}
+
+	key := Secret["archive-project"]
+	if _, err := UpdateRemoteRepo(repoSlug, map[string]any{"project": map[string]string{"key": key}}); err != nil {
+		return WrapError(err, 186, Secret["workspace"]+"/"+repoSlug, key)
+	}
+	ResetRemoteLabels()
+	ReportData("project-key", key)
+
+	if projectRoot != "" {
+		LeaveProject(projectRoot, workspaceDir)
+		if err := os.RemoveAll(projectRoot); err != nil {
+			return WrapError(err, 187, projectRoot)
+		}
+		ReportData("removed", projectRoot)
+	}
+
+	return Success("archived", Secret["workspace"]+"/"+repoSlug, key)
+}
@@ -0,0 +1,233 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newRepoServer returns a server which updates the repositories and records the request bodies by slug
+func newRepoServer(t *testing.T, status int, requests map[string]map[string]any) *httptest.Server {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "PUT", r.Method)
+		slug := strings.TrimPrefix(r.URL.Path, "/repositories/"+Secret["workspace"]+"/")
+
+		body := map[string]any{}
+		json.NewDecoder(r.Body).Decode(&body)
+		requests[slug] = body
+
+		w.WriteHeader(status)
+		if name, ok := body["name"].(string); ok {
+			slug = strings.ToLower(strings.ReplaceAll(name, " ", ""))
+		}
+		w.Write([]byte(`{"slug": "` + slug + `"}`))
+	}))
+
+	original := BitbucketApiUrl
+	BitbucketApiUrl = server.URL
+	t.Cleanup(func() { BitbucketApiUrl = original })
+	return server
+}
+
+func TestUpdateRemoteRepo(t *testing.T) {
+	requests := map[string]map[string]any{}
+	server := newRepoServer(t, http.StatusOK, requests)
+
+	slug, err := UpdateRemoteRepo("7984-a", map[string]any{"name": "7984 - B"})
+	assert.Nil(t, err)
+	assert.Equal(t, "7984-b", slug)
+	assert.Equal(t, map[string]any{"name": "7984 - B"}, requests["7984-a"])
+
+	server.Close()
+	_, err = UpdateRemoteRepo("7984-a", map[string]any{"name": "7984 - B"})
+	assert.NotNil(t, err)
+
+	newRepoServer(t, http.StatusNotFound, requests)
+	_, err = UpdateRemoteRepo("7984-a", map[string]any{"name": "7984 - B"})
+	assert.Equal(t, "unexpected status 404 Not Found", err.Error())
+}
+
+// unpushedChanges returns the commands which count the changes not pushed to the server
+func unpushedChanges(status string, stashes string, commits string) []CommandSpec {
+	return []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", status, "", 0},
+		{"git stash list", stashes, "", 0},
+		{"git log --branches --not --remotes --format=%h", commits, "", 0},
+	}
+}
+
+func TestGetUnpushedChanges(t *testing.T) {
+	setup()
+	defer teardown()
+
+	MockCommandsQueue = unpushedChanges(" M a.txt\n?? b.txt\n", "stash@{0}: WIP\n", "abc1234\n")[1:]
+	changes, stashes, commits, err := GetUnpushedChanges(t.TempDir())
+	assert.Nil(t, err)
+	assert.Equal(t, []int{2, 1, 1}, []int{changes, stashes, commits})
+
+	// Nothing is known if Git fails
+	MockCommandsQueue = []CommandSpec{
+		{"git status --porcelain", "", "", 0},
+		{"git stash list", "", "", 0},
+		{"git log --branches --not --remotes --format=%h", "", "fatal: bad revision", 128},
+	}
+	_, _, _, err = GetUnpushedChanges(t.TempDir())
+	assert.NotNil(t, err)
+}
+
+func TestRenameCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requests := map[string]map[string]any{}
+	newRepoServer(t, http.StatusOK, requests)
+
+	workspace := createWorkspace(t, "7984-A", "7984-C")
+	oldRoot := filepath.Join(workspace, "7984-A")
+	newRoot := filepath.Join(workspace, "7984-B")
+	WriteProsProject(oldRoot, NewProsProject("7984-A"))
+
+	assert.Equal(t, 200, GetErrorCode(RenameCommand("A", "b", workspace)))
+	assert.Equal(t, 200, GetErrorCode(RenameCommand("../..", "B", workspace)))
+
+	MockCommandsQueue = unpushedChanges("", "", "")
+	assert.Equal(t, 181, GetErrorCode(RenameCommand("A", "C", workspace)))
+
+	MockCommandsQueue = unpushedChanges("?? notes.txt\n", "", "")
+	assert.Equal(t, 173, GetErrorCode(RenameCommand("A", "B", workspace)))
+	assert.Equal(t, 0, len(requests))
+
+	MockCommandsQueue = append(unpushedChanges("", "", ""), []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git remote get-url origin", GetRepoUrl("7984-a"), "", 0},
+		{"git remote set-url origin " + GetRepoUrl("7984-b"), "", "", 0},
+		{"git config user.name " + Secret["computer-name"], "", "", 0},
+		{"git config user.email " + Secret["email"], "", "", 0},
+		{"git config commit.gpgsign false", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit --allow-empty -m Rename A to B", "", "", 0},
+	}...)
+	assert.Nil(t, RenameCommand("A", "B", workspace))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+	assert.Equal(t, map[string]any{"name": "7984 - B"}, requests["7984-a"])
+
+	_, err := os.Stat(oldRoot)
+	assert.True(t, os.IsNotExist(err))
+	project, err := ReadProsProject(newRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, "7984-B", project.ProjectName)
+
+	// Only the remote repository is renamed if there is no local project, and the cached labels are dropped
+	remoteLabels = []string{"D"}
+	BeginCommandResult("rename", []string{"D", "E"})
+	assert.Nil(t, RenameCommand("D", "E", workspace))
+	assert.Equal(t, map[string]any{"name": "7984 - E"}, requests["7984-d"])
+	assert.Equal(t, "7984-d", CurrentResult.Data["old-repo-slug"])
+	assert.Equal(t, "7984-e", CurrentResult.Data["repo-slug"])
+	assert.Nil(t, GetCachedRemoteLabels())
+	EndCommandResult(false)
+
+	// The local project is moved back if the remote repository cannot be renamed
+	newRepoServer(t, http.StatusForbidden, requests)
+	MockCommandsQueue = unpushedChanges("", "", "")
+	assert.Equal(t, 182, GetErrorCode(RenameCommand("B", "F", workspace)))
+	assert.DirExists(t, newRoot)
+	assert.NoDirExists(t, filepath.Join(workspace, "7984-F"))
+
+	// The remote repository is renamed, but the local project cannot be linked
+	newRepoServer(t, http.StatusOK, requests)
+	MockCommandsQueue = append(unpushedChanges("", "", ""), []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git remote get-url origin", "", "", 2},
+		{"git remote add origin " + GetRepoUrl("7984-f"), "", "", 1},
+	}...)
+	err = RenameCommand("B", "F", workspace)
+	assert.Equal(t, 191, GetErrorCode(err))
+	assert.DirExists(t, filepath.Join(workspace, "7984-F"))
+}
+
+func TestRenameCommandActiveProject(t *testing.T) {
+	setup()
+	defer teardown()
+
+	AdminDir = t.TempDir()
+	defer func() { AdminDir = "" }()
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	original := WorkingDir
+	defer func() { WorkingDir = original }()
+
+	requests := map[string]map[string]any{}
+	newRepoServer(t, http.StatusForbidden, requests)
+
+	workspace := createWorkspace(t, "7984-A")
+	oldRoot := filepath.Join(workspace, "7984-A")
+	newRoot := filepath.Join(workspace, "7984-B")
+	assert.Nil(t, ChangeProject(oldRoot))
+
+	// The active project is back where it was if the local project is moved back
+	MockCommandsQueue = unpushedChanges("", "", "")
+	assert.Equal(t, 182, GetErrorCode(RenameCommand("A", "B", workspace)))
+	assert.Equal(t, oldRoot, WorkingDir)
+
+	// The renamed project is the active project
+	newRepoServer(t, http.StatusOK, requests)
+	MockCommandsQueue = append(unpushedChanges("", "", ""), []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git remote get-url origin", GetRepoUrl("7984-a"), "", 0},
+		{"git remote set-url origin " + GetRepoUrl("7984-b"), "", "", 0},
+		{"git config user.name " + Secret["computer-name"], "", "", 0},
+		{"git config user.email " + Secret["email"], "", "", 0},
+		{"git config commit.gpgsign false", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit --allow-empty -m Rename A to B", "", "", 0},
+	}...)
+	assert.Nil(t, RenameCommand("A", "B", workspace))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+	assert.Equal(t, newRoot, WorkingDir)
+}
+
+func TestArchiveCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	requests := map[string]map[string]any{}
+	newRepoServer(t, http.StatusOK, requests)
+
+	workspace := createWorkspace(t, "7984-A")
+	projectRoot := filepath.Join(workspace, "7984-A")
+
+	// The label must not lead out of the workspace directory
+	assert.Equal(t, 200, GetErrorCode(ArchiveCommand("../..", workspace)))
+	assert.Equal(t, "", FindProjectByLabel("../..", workspace))
+
+	MockCommandsQueue = unpushedChanges("", "", "abc1234\n")
+	assert.Equal(t, 185, GetErrorCode(ArchiveCommand("A", workspace)))
+	MockCommandsQueue = unpushedChanges("", "stash@{0}: WIP\n", "")
+	assert.Equal(t, 185, GetErrorCode(ArchiveCommand("A", workspace)))
+
+	// Nothing is removed if Git cannot tell
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "fatal: not a git repository", 128},
+	}
+	assert.Equal(t, 190, GetErrorCode(ArchiveCommand("A", workspace)))
+	assert.Equal(t, 0, len(requests))
+	assert.DirExists(t, projectRoot)
+
+	MockCommandsQueue = unpushedChanges("", "", "")
+	assert.Nil(t, ArchiveCommand("A", workspace))
+	assert.Equal(t, map[string]any{"project": map[string]any{"key": "ARCHIVE"}}, requests["7984-a"])
+	assert.NoDirExists(t, projectRoot)
+
+	newRepoServer(t, http.StatusForbidden, requests)
+	assert.Equal(t, 186, GetErrorCode(ArchiveCommand("B", workspace)))
+}
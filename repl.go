@@ -0,0 +1,199 @@
+package main
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/peterh/liner"
+)
+
+var (
+	// remoteLabels caches the labels of the remote repositories, fetched on first use
+	remoteLabels []string
+	// remoteLabelsLock guards remoteLabels, which is prefetched in the background
+	remoteLabelsLock sync.Mutex
+)
+
+// GetReplHistoryFilePath returns the path of the command line history file in the administrator directory.
+// No side effect
+func GetReplHistoryFilePath() string {
+	return filepath.Join(AdminDir, ".cmapi-cli-repl-history")
+}
+
+// NewLineEditor returns a line editor with the command line history loaded and tab completion set up
+func NewLineEditor() *liner.State {
+	line := liner.NewLiner()
+	line.SetCtrlCAborts(true)
+	line.SetTabCompletionStyle(liner.TabPrints)
+	line.SetWordCompleter(CompleteCommandLine)
+
+	// The completion must not wait for the server
+	go GetRemoteLabels()
+
+	if file, err := os.Open(GetReplHistoryFilePath()); err == nil {
+		line.ReadHistory(file)
+		file.Close()
+	}
+
+	return line
+}
+
+// SaveReplHistory writes the command line history to the administrator directory
+func SaveReplHistory(line *liner.State) bool {
+	file, err := os.OpenFile(GetReplHistoryFilePath(), os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return false
+	}
+	defer file.Close()
+
+	_, err = line.WriteHistory(file)
+	return err == nil
+}
+
+// ShouldSaveHistory returns false if the command line sets a secret, which must not be written to the history
+// file.
+// No side effect
+func ShouldSaveHistory(commandLine string) bool {
+	items, err := SplitCommandChain(commandLine)
+	if err != nil {
+		return true
+	}
+
+	for _, item := range items {
+		fields := strings.Fields(item.CommandLine)
+		if len(fields) > 2 {
+			if cmd := FindCommand(fields[0]); cmd != nil && cmd.Name == "secret" {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// GetFlagNames returns the names of all flags in the flag set, with one dash for short names and two for long names.
+// No side effect
+func GetFlagNames(fs *flag.FlagSet) []string {
+	rtn := []string{}
+	fs.VisitAll(func(f *flag.Flag) {
+		if len(f.Name) <= 2 {
+			rtn = append(rtn, "-"+f.Name)
+		} else {
+			rtn = append(rtn, "--"+f.Name)
+		}
+	})
+	return rtn
+}
+
+// FetchRemoteLabels returns the labels of the repositories on the server with the repo slug prefix, sorted,
+// and updates the cache.
+func FetchRemoteLabels() ([]string, error) {
+	slugs, err := ListRemoteRepos()
+	if err != nil {
+		return nil, err
+	}
+
+	prefix := strings.ToLower(Secret["repo-slug-prefix"])
+	labels := []string{}
+	for _, slug := range slugs {
+		if strings.HasPrefix(slug, prefix) {
+			labels = append(labels, strings.ToUpper(strings.TrimPrefix(slug, prefix)))
+		}
+	}
+	sort.Strings(labels)
+
+	remoteLabelsLock.Lock()
+	defer remoteLabelsLock.Unlock()
+	remoteLabels = labels
+	return labels, nil
+}
+
+// GetRemoteLabels returns the labels of the repositories on the server with the repo slug prefix.
+// The result is cached for the session. An empty list is returned if the server cannot be reached.
+func GetRemoteLabels() []string {
+	if labels := GetCachedRemoteLabels(); labels != nil {
+		return labels
+	}
+
+	labels, err := FetchRemoteLabels()
+	if err != nil {
+		return []string{}
+	}
+	return labels
+}
+
+// GetCachedRemoteLabels returns the cached labels of the repositories on the server, or nil if they are not
+// fetched yet.
+// No side effect
+func GetCachedRemoteLabels() []string {
+	remoteLabelsLock.Lock()
+	defer remoteLabelsLock.Unlock()
+	return remoteLabels
+}
+
+// ResetRemoteLabels clears the cached labels after the repositories on the server are changed
+func ResetRemoteLabels() {
+	remoteLabelsLock.Lock()
+	defer remoteLabelsLock.Unlock()
+	remoteLabels = nil
+}
+
+// CompleteCommandLine returns the candidates of the word at the cursor.
+func CompleteCommandLine(line string, pos int) (string, []string, string) {
+	head := line[:pos]
+	tail := line[pos:]
+
+	word := head
+	if i := strings.LastIndexAny(head, " \t"); i != -1 {
+		word = head[i+1:]
+	}
+	head = head[:len(head)-len(word)]
+
+	// Only the last command in a chain matters
+	segment := head
+	if i := strings.LastIndexAny(segment, ";&"); i != -1 {
+		segment = segment[i+1:]
+	}
+	fields := strings.Fields(segment)
+
+	var candidates []string
+	var cmd *Command
+	if len(fields) != 0 {
+		cmd = FindCommand(fields[0])
+	}
+
+	if len(fields) == 0 {
+		candidates = append(GetSortedKeys(GetAliases()), GetCommandNames()...)
+	} else if cmd == nil {
+		candidates = []string{}
+	} else if strings.HasPrefix(word, "-") {
+		candidates = GetFlagNames(NewCommandFlagSet(cmd, &CommandOptions{}))
+	} else if len(cmd.Actions) != 0 && len(fields) == 1 {
+		candidates = cmd.Actions
+	} else if cmd.Name == "secret" && len(fields) == 1 {
+		candidates = GetSortedKeys(Secret)
+	} else if cmd.Name == "clone" {
+		candidates = append([]string{}, GetCachedRemoteLabels()...)
+	} else if cmd.Name == "cd" || cmd.Name == "archive" || (cmd.Name == "rename" && len(fields) == 1) {
+		candidates = GetLocalLabels(Secret["workspace-dir"])
+	} else if cmd.Name == "open" {
+		candidates = GetLocalLabels(Secret["workspace-dir"])
+		for _, label := range GetCachedRemoteLabels() {
+			if !Contains(candidates, label) {
+				candidates = append(candidates, label)
+			}
+		}
+	}
+
+	completions := []string{}
+	for _, candidate := range candidates {
+		if strings.HasPrefix(candidate, word) {
+			completions = append(completions, candidate+" ")
+		}
+	}
+
+	return head, completions, tail
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCompleteCommandLine(t *testing.T) {
+	head, completions, tail := CompleteCommandLine("ba", 2)
+	assert.Equal(t, "", head)
+	assert.Equal(t, []string{"backup "}, completions)
+	assert.Equal(t, "", tail)
+
+	head, completions, tail = CompleteCommandLine("normal --sl extra", 11)
+	assert.Equal(t, "normal ", head)
+	assert.Equal(t, []string{"--slot "}, completions)
+	assert.Equal(t, " extra", tail)
+
+	head, completions, _ = CompleteCommandLine("pull && ba", 10)
+	assert.Equal(t, "pull && ", head)
+	assert.Equal(t, []string{"backup "}, completions)
+
+	_, completions, _ = CompleteCommandLine("variants b", 10)
+	assert.Equal(t, []string{"build "}, completions)
+
+	_, completions, _ = CompleteCommandLine("secret repo-", 12)
+	assert.Equal(t, []string{"repo-name-prefix ", "repo-slug-prefix "}, completions)
+
+	// The remote labels are skipped until they are fetched
+	_, completions, _ = CompleteCommandLine("clone W", 7)
+	assert.Equal(t, 0, len(completions))
+
+	remoteLabels = []string{"SKILLS", "WORLDS-2025"}
+	defer ResetRemoteLabels()
+
+	_, completions, _ = CompleteCommandLine("clone W", 7)
+	assert.Equal(t, []string{"WORLDS-2025 "}, completions)
+}
+
+func TestListRemoteRepos(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		user, _, ok := r.BasicAuth()
+		assert.True(t, ok)
+		assert.Equal(t, Secret["username"], user)
+
+		if r.URL.Query().Get("page") == "2" {
+			w.Write([]byte(`{"values": [{"slug": "7984-worlds-2025"}]}`))
+		} else {
+			w.Write([]byte(`{"next": "` + server.URL + `/repositories/x?page=2", "values": [{"slug": "7984-skills"}, {"slug": "other"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	original := BitbucketApiUrl
+	BitbucketApiUrl = server.URL
+	defer func() { BitbucketApiUrl = original }()
+
+	slugs, err := ListRemoteRepos()
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"7984-skills", "other", "7984-worlds-2025"}, slugs)
+
+	assert.Equal(t, []string{"SKILLS", "WORLDS-2025"}, GetRemoteLabels())
+	assert.Equal(t, []string{"SKILLS", "WORLDS-2025"}, GetCachedRemoteLabels())
+	ResetRemoteLabels()
+	assert.Nil(t, GetCachedRemoteLabels())
+}
+
+func TestShouldSaveHistory(t *testing.T) {
+	assert.True(t, ShouldSaveHistory("pull && build"))
+	assert.True(t, ShouldSaveHistory("secret"))
+	assert.True(t, ShouldSaveHistory("secret password"))
+	assert.False(t, ShouldSaveHistory("secret password hunter2"))
+	assert.False(t, ShouldSaveHistory("pull && secret email a@b.c"))
+}
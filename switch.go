@@ -0,0 +1,208 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// RecentProject is a project which was the active project
+type RecentProject struct {
+	Path     string    `json:"path"`
+	LastUsed time.Time `json:"last-used"`
+}
+
+// MaxRecentProjects is the number of projects kept in the recent project file
+const MaxRecentProjects = 10
+
+// GetRecentFilePath returns the path of the recent project file in the administrator directory.
+// No side effect
+func GetRecentFilePath() string {
+	return filepath.Join(AdminDir, ".cmapi-cli-recent.json")
+}
+
+// ReadRecentProjects returns the recent projects, the most recent first. An empty list is returned if the
+// file cannot be read.
+// No side effect
+func ReadRecentProjects(filename string) []RecentProject {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return []RecentProject{}
+	}
+
+	rtn := []RecentProject{}
+	if json.Unmarshal(data, &rtn) != nil {
+		return []RecentProject{}
+	}
+	return rtn
+}
+
+// AddRecentProject moves the project to the top of the recent project file, and drops the oldest projects
+// over the limit.
+// No side effect
+func AddRecentProject(filename string, projectRoot string) bool {
+	recent := []RecentProject{{Path: projectRoot, LastUsed: time.Now()}}
+	for _, project := range ReadRecentProjects(filename) {
+		if project.Path != projectRoot && len(recent) < MaxRecentProjects {
+			recent = append(recent, project)
+		}
+	}
+
+	data, err := json.MarshalIndent(recent, "", "    ")
+	if err != nil {
+		return false
+	}
+	return os.WriteFile(filename, data, 0600) == nil
+}
+
+// GetLocalLabels returns the labels of the projects in the workspace directory, sorted.
+// No side effect
+func GetLocalLabels(workspaceDir string) []string {
+	rtn := []string{}
+	entries, _ := os.ReadDir(workspaceDir)
+	for _, entry := range entries {
+		if entry.IsDir() && !strings.HasPrefix(entry.Name(), ".") {
+			rtn = append(rtn, GetProjectLabel(entry.Name()))
+		}
+	}
+	sort.Strings(rtn)
+	return rtn
+}
+
+// FindProjectByLabel returns the root of the project with the label in the workspace directory, or an empty
+// string if not found. The label is case-insensitive, and the project is always a direct child of the
+// workspace directory.
+// No side effect
+func FindProjectByLabel(label string, workspaceDir string) string {
+	// The label must not lead out of the workspace directory
+	projectRoot := filepath.Join(workspaceDir, Secret["repo-slug-prefix"]+label)
+	if filepath.Dir(projectRoot) != filepath.Clean(workspaceDir) {
+		return ""
+	}
+	if info, err := os.Stat(projectRoot); err == nil && info.IsDir() {
+		return projectRoot
+	}
+
+	entries, _ := os.ReadDir(workspaceDir)
+	for _, entry := range entries {
+		if entry.IsDir() && strings.EqualFold(GetProjectLabel(entry.Name()), label) {
+			return filepath.Join(workspaceDir, entry.Name())
+		}
+	}
+	return ""
+}
+
+// ResolveProject returns the directory of the path, relative to the active project, or of the project with
+// the label in the workspace directory. Returns an empty string if neither exists.
+// No side effect
+func ResolveProject(target string, workspaceDir string) string {
+	path := target
+	if home, err := os.UserHomeDir(); err == nil && (path == "~" || strings.HasPrefix(path, "~/")) {
+		path = filepath.Join(home, path[1:])
+	}
+	if !filepath.IsAbs(path) {
+		path = filepath.Join(WorkingDir, path)
+	}
+	if info, err := os.Stat(path); err == nil && info.IsDir() {
+		return filepath.Clean(path)
+	}
+
+	return FindProjectByLabel(NormalizeLabel(target), workspaceDir)
+}
+
+// GetBranchName returns the branch of the repository from '.git/HEAD', or the short commit hash if the HEAD
+// is detached. Returns an empty string if it is not the root of a repository.
+// No side effect
+func GetBranchName(projectRoot string) string {
+	data, err := os.ReadFile(filepath.Join(projectRoot, ".git", "HEAD"))
+	if err != nil {
+		return ""
+	}
+
+	head := strings.TrimSpace(string(data))
+	if strings.HasPrefix(head, "ref: refs/heads/") {
+		return strings.TrimPrefix(head, "ref: refs/heads/")
+	}
+	return ShortCommit(head)
+}
+
+// GetPrompt returns the prompt with the label and the branch of the active project, if any.
+// No side effect
+func GetPrompt() string {
+	if !IsProsProject(WorkingDir) && GetBranchName(WorkingDir) == "" {
+		return "> "
+	}
+
+	prompt := GetProjectLabel(WorkingDir)
+	if branch := GetBranchName(WorkingDir); branch != "" {
+		prompt += " (" + branch + ")"
+	}
+	return prompt + "> "
+}
+
+// ChangeProject makes the directory the active project and records it as a recent project
+func ChangeProject(projectRoot string) error {
+	if err := os.Chdir(projectRoot); err != nil {
+		return WrapError(err, 171, projectRoot)
+	}
+	WorkingDir = projectRoot
+	AddRecentProject(GetRecentFilePath(), projectRoot)
+
+	ReportData("project", projectRoot)
+	WarnKernelMismatch(projectRoot)
+	return Success("project-changed", projectRoot)
+}
+
+// CdCommand makes the directory or the project with the label in the workspace directory the active project
+func CdCommand(target string, workspaceDir string) error {
+	projectRoot := ResolveProject(target, workspaceDir)
+	if projectRoot == "" {
+		return NewError(170, target)
+	}
+	return ChangeProject(projectRoot)
+}
+
+// OpenCommand makes the project with the label the active project. The project is cloned first if it is not
+// in the workspace directory.
+func OpenCommand(label string, workspaceDir string, kernel string, noPull bool, yes bool) error {
+	projectRoot := FindProjectByLabel(label, workspaceDir)
+	if projectRoot == "" {
+		if !IsValidLabel(label) {
+			return NewError(200)
+		}
+		label, err := ResolveRemoteLabel(label, yes)
+		if err != nil {
+			return err
+		}
+		if err := CloneRepositoryCommand(label, workspaceDir, kernel, noPull); err != nil {
+			return err
+		}
+		projectRoot = filepath.Join(workspaceDir, Secret["repo-slug-prefix"]+label)
+	}
+	return ChangeProject(projectRoot)
+}
+
+// RecentCommand lists the projects which were recently the active project
+func RecentCommand() error {
+	recent := ReadRecentProjects(GetRecentFilePath())
+	ReportData("projects", recent)
+	if len(recent) == 0 {
+		return Success("recent-empty")
+	}
+
+	for _, project := range recent {
+		line := fmt.Sprintf("%-20s %s  %s", GetProjectLabel(project.Path),
+			project.LastUsed.Local().Format("2006-01-02 15:04"), project.Path)
+		if project.Path == WorkingDir {
+			fmt.Fprintln(Console, HeaderText(line))
+		} else {
+			fmt.Fprintln(Console, line)
+		}
+	}
+
+	return nil
+}
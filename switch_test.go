@@ -0,0 +1,100 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAddRecentProject(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "recent.json")
+	assert.Equal(t, []RecentProject{}, ReadRecentProjects(filename))
+
+	for i := 0; i < MaxRecentProjects+2; i++ {
+		assert.True(t, AddRecentProject(filename, filepath.Join("workspace", string(rune('A'+i)))))
+	}
+	recent := ReadRecentProjects(filename)
+	assert.Equal(t, MaxRecentProjects, len(recent))
+	assert.Equal(t, filepath.Join("workspace", "L"), recent[0].Path)
+	assert.Equal(t, filepath.Join("workspace", "C"), recent[MaxRecentProjects-1].Path)
+
+	// A project is moved to the top instead of added again
+	AddRecentProject(filename, filepath.Join("workspace", "E"))
+	recent = ReadRecentProjects(filename)
+	assert.Equal(t, MaxRecentProjects, len(recent))
+	assert.Equal(t, filepath.Join("workspace", "E"), recent[0].Path)
+	assert.Equal(t, filepath.Join("workspace", "L"), recent[1].Path)
+
+	os.WriteFile(filename, []byte("invalid"), 0600)
+	assert.Equal(t, []RecentProject{}, ReadRecentProjects(filename))
+}
+
+func TestResolveProject(t *testing.T) {
+	workspace := createWorkspace(t, "7984-A", "7984-b")
+	assert.Equal(t, []string{"A", "b", "notes"}, GetLocalLabels(workspace))
+
+	assert.Equal(t, filepath.Join(workspace, "7984-A"), FindProjectByLabel("A", workspace))
+	assert.Equal(t, filepath.Join(workspace, "7984-b"), FindProjectByLabel("B", workspace))
+	assert.Equal(t, "", FindProjectByLabel("C", workspace))
+
+	original := WorkingDir
+	WorkingDir = filepath.Join(workspace, "7984-A")
+	defer func() { WorkingDir = original }()
+
+	assert.Equal(t, filepath.Join(workspace, "7984-b"), ResolveProject("../7984-b", workspace))
+	assert.Equal(t, filepath.Join(workspace, "notes"), ResolveProject(filepath.Join(workspace, "notes"), workspace))
+	assert.Equal(t, filepath.Join(workspace, "7984-b"), ResolveProject("B", workspace))
+	assert.Equal(t, "", ResolveProject("C", workspace))
+}
+
+func TestGetPrompt(t *testing.T) {
+	workspace := createWorkspace(t, "7984-A")
+	projectRoot := filepath.Join(workspace, "7984-A")
+
+	original := WorkingDir
+	defer func() { WorkingDir = original }()
+
+	WorkingDir = workspace
+	assert.Equal(t, "> ", GetPrompt())
+
+	WorkingDir = projectRoot
+	os.WriteFile(filepath.Join(projectRoot, ".git", "HEAD"), []byte("ref: refs/heads/main\n"), 0644)
+	assert.Equal(t, "main", GetBranchName(projectRoot))
+	assert.Equal(t, "A (main)> ", GetPrompt())
+
+	os.WriteFile(filepath.Join(projectRoot, ".git", "HEAD"), []byte("0123456789abcdef\n"), 0644)
+	assert.Equal(t, "A (0123456)> ", GetPrompt())
+}
+
+func TestCdCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	AdminDir = t.TempDir()
+	defer func() { AdminDir = "" }()
+
+	cwd, _ := os.Getwd()
+	defer os.Chdir(cwd)
+	original := WorkingDir
+	defer func() { WorkingDir = original }()
+
+	workspace := createWorkspace(t, "A")
+	assert.Equal(t, 170, GetErrorCode(CdCommand("B", workspace)))
+
+	assert.Nil(t, CdCommand("A", workspace))
+	assert.Equal(t, filepath.Join(workspace, "A"), WorkingDir)
+	assert.Nil(t, CdCommand("../notes", workspace))
+	assert.Equal(t, filepath.Join(workspace, "notes"), WorkingDir)
+
+	recent := ReadRecentProjects(GetRecentFilePath())
+	assert.Equal(t, 2, len(recent))
+	assert.Equal(t, filepath.Join(workspace, "notes"), recent[0].Path)
+	assert.Nil(t, RecentCommand())
+
+	// The project does not exist and the label is invalid
+	assert.Equal(t, 200, GetErrorCode(OpenCommand("b", workspace, "", true, false)))
+	assert.Nil(t, OpenCommand("A", workspace, "", true, false))
+	assert.Equal(t, filepath.Join(workspace, "A"), WorkingDir)
+}
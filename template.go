@@ -0,0 +1,149 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Returns true if the given template name is valid
+// No side effect
+var IsValidTemplateName = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`).MatchString
+
+// ParseTemplateSpec parses a template like "okapilib@4.8.0". The version is "latest" if not specified.
+// No side effect
+func ParseTemplateSpec(spec string) (string, string, bool) {
+	name, version, found := strings.Cut(spec, "@")
+	if !found || version == "" {
+		version = "latest"
+	}
+
+	if !IsValidTemplateName(name) || (version != "latest" && !IsValidKernelVersion(version)) {
+		return "", "", false
+	}
+
+	return name, version, true
+}
+
+// ApplyTemplate installs or upgrades the template in the PROS project
+func ApplyTemplate(projectRoot string, name string, version string, noPull bool) error {
+	args := []string{"conductor", "apply", name + "@" + version, "--force-apply"}
+	if noPull {
+		args = append(args, "--no-download")
+	}
+
+	out, errs, code := RunCommandPrintOut(projectRoot, "pros", args...)
+	if strings.Contains(out, "ERROR") || strings.Contains(errs, "ERROR") || code != 0 {
+		return WrapError(GetExitError(code, "pros", args...), 150, name+"@"+version)
+	}
+	return nil
+}
+
+// ApplyProjectTemplates applies all templates recorded in the project config
+func ApplyProjectTemplates(projectRoot string, noPull bool) error {
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+
+	for _, name := range GetSortedKeys(config.Templates) {
+		if err := ApplyTemplate(projectRoot, name, config.Templates[name], noPull); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// GetSortedKeys returns the keys of the map in ascending order.
+// No side effect
+func GetSortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// TemplateCommand adds, removes, lists or updates the templates that the project depends on
+func TemplateCommand(projectRoot string, action string, spec string, noPull bool) error {
+	if !IsProsProject(projectRoot) {
+		return NewError(134)
+	}
+
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+	if config.Templates == nil {
+		config.Templates = map[string]string{}
+	}
+
+	if action == "" || action == "list" {
+		installed := GetInstalledTemplates(projectRoot)
+		ReportData("templates", config.Templates)
+		ReportData("installed", installed)
+
+		fmt.Fprintln(Console, HeaderText(T("templates-listing")))
+		for _, name := range GetSortedKeys(config.Templates) {
+			version := installed[name]
+			if version == "" {
+				version = T("template-missing")
+			}
+			fmt.Fprintln(Console, InfoText(name+": ")+T("template-installed", config.Templates[name], version))
+		}
+		return nil
+	}
+
+	if action != "add" && action != "remove" && action != "update" {
+		return NewError(201, action)
+	}
+
+	specs := []string{spec}
+	if action == "update" && spec == "" {
+		specs = GetSortedKeys(config.Templates)
+	}
+
+	for _, spec := range specs {
+		name, version, ok := ParseTemplateSpec(spec)
+		if !ok {
+			return NewError(204, spec)
+		}
+		if name == "kernel" {
+			return NewError(205)
+		}
+
+		if action == "remove" {
+			if _, ok := config.Templates[name]; !ok {
+				return NewError(152, name)
+			}
+			if err := RunCommandGetError(projectRoot, "pros", "conductor", "uninstall", name); err != nil {
+				return WrapError(err, 151, name)
+			}
+			delete(config.Templates, name)
+			continue
+		}
+
+		if _, ok := config.Templates[name]; action == "update" && !ok {
+			return NewError(152, name)
+		}
+
+		if err := ApplyTemplate(projectRoot, name, version, noPull); err != nil {
+			return err
+		}
+
+		// Record the exact version so that fresh clones get the same template
+		if installed := GetInstalledTemplates(projectRoot)[name]; installed != "" {
+			version = installed
+		}
+		config.Templates[name] = version
+	}
+
+	if err := WriteProjectConfig(projectRoot, config); err != nil {
+		return WrapError(err, 144)
+	}
+
+	return Success("templates-update")
+}
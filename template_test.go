@@ -0,0 +1,61 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseTemplateSpec(t *testing.T) {
+	name, version, ok := ParseTemplateSpec("okapilib@4.8.0")
+	assert.Equal(t, "okapilib", name)
+	assert.Equal(t, "4.8.0", version)
+	assert.True(t, ok)
+
+	name, version, ok = ParseTemplateSpec("LemLib")
+	assert.Equal(t, "LemLib", name)
+	assert.Equal(t, "latest", version)
+	assert.True(t, ok)
+
+	_, _, ok = ParseTemplateSpec("okapilib@four")
+	assert.False(t, ok)
+	_, _, ok = ParseTemplateSpec("@4.8.0")
+	assert.False(t, ok)
+	_, _, ok = ParseTemplateSpec("okapi lib")
+	assert.False(t, ok)
+}
+
+func TestTemplateCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	os.WriteFile("project.pros", []byte(testProjectPros), 0644)
+
+	MockCommandsQueue = []CommandSpec{
+		// add, the installed version is recorded
+		{"pros conductor apply okapilib@latest --force-apply", "", "", 0},
+		// add, failed to apply
+		{"pros conductor apply LemLib@0.4.7 --force-apply --no-download", "", "ERROR - not found", 0},
+		// update all
+		{"pros conductor apply okapilib@latest --force-apply", "", "", 0},
+		// remove
+		{"pros conductor uninstall okapilib", "", "", 0},
+	}
+
+	assert.Nil(t, TemplateCommand(wd, "add", "okapilib", false))
+	assert.Equal(t, map[string]string{"okapilib": "4.8.0"}, ReadProjectConfig(wd).Templates)
+
+	assert.Equal(t, 150, GetErrorCode(TemplateCommand(wd, "add", "LemLib@0.4.7", true)))
+	assert.Equal(t, 205, GetErrorCode(TemplateCommand(wd, "add", "kernel@3.8.0", false)))
+	assert.Equal(t, 152, GetErrorCode(TemplateCommand(wd, "update", "LemLib", false)))
+	assert.Nil(t, TemplateCommand(wd, "update", "", false))
+	assert.Nil(t, TemplateCommand(wd, "list", "", false))
+
+	assert.Nil(t, TemplateCommand(wd, "remove", "okapilib", false))
+	assert.Empty(t, ReadProjectConfig(wd).Templates)
+	assert.Equal(t, 152, GetErrorCode(TemplateCommand(wd, "remove", "okapilib", false)))
+	assert.Empty(t, MockCommandsQueue)
+}
@@ -0,0 +1,226 @@
+package main
+
+import (
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// TemplatePatchPath is the path of the template changes in the project, in the Git directory so that it is
+// never committed
+var TemplatePatchPath = filepath.Join(".git", "cmapi-template.patch")
+
+// TemplateWorkPath is the path of the clone of the template in the project, where the template is checked out
+// with the variables substituted
+var TemplateWorkPath = filepath.Join(".git", "cmapi-template")
+
+// GetHeadCommit returns the commit hash of HEAD in the repository, or an empty string if there is none.
+// No side effect
+func GetHeadCommit(repoRoot string) string {
+	out, _, code := RunCommandGetOutput(repoRoot, "git", "rev-parse", "HEAD")
+	if code != 0 {
+		return ""
+	}
+	return strings.TrimSpace(out)
+}
+
+// ShortCommit returns the first 7 characters of the commit hash.
+// No side effect
+func ShortCommit(commit string) string {
+	if len(commit) > 7 {
+		return commit[:7]
+	}
+	return commit
+}
+
+// RecordTemplate records the name, the HEAD commit and the variables of the template in the project config.
+// No commit is recorded if the template is a local directory without Git.
+func RecordTemplate(projectRoot string, templateRoot string, name string, variables map[string]string) error {
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+
+	config.Template = name
+	config.TemplateVariables = variables
+	if _, err := os.Stat(filepath.Join(templateRoot, ".git")); err == nil {
+		config.TemplateCommit = GetHeadCommit(templateRoot)
+	}
+	return WriteProjectConfig(projectRoot, config)
+}
+
+// GetConflictedFiles returns the files with unresolved conflicts in the repository.
+// No side effect
+func GetConflictedFiles(projectRoot string) []string {
+	out, _, _ := RunCommandGetOutput(projectRoot, "git", "diff", "--name-only", "--diff-filter=U")
+
+	rtn := []string{}
+	for _, line := range strings.Split(out, "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			rtn = append(rtn, line)
+		}
+	}
+	return rtn
+}
+
+// ListTemplateFiles returns the files and directories in the template directory other than the Git directory,
+// parents before their children.
+// No side effect
+func ListTemplateFiles(templateRoot string) ([]string, error) {
+	rtn := []string{}
+	err := filepath.WalkDir(templateRoot, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		if path == templateRoot {
+			return nil
+		}
+		if entry.IsDir() && entry.Name() == ".git" {
+			return filepath.SkipDir
+		}
+		rtn = append(rtn, path)
+		return nil
+	})
+	return rtn, err
+}
+
+// CommitSubstitutedTemplate checks out the commit of the template in the clone on the branch, substitutes the
+// variables in the files and commits them. Returns the new commit.
+func CommitSubstitutedTemplate(workRoot string, commit string, branch string, variables map[string]string) (string, error) {
+	if err := RunCommandsGetError(workRoot,
+		[]string{"git", "checkout", "-q", "-f", "-B", branch, commit},
+		[]string{"git", "clean", "-q", "-f", "-d", "-x"}); err != nil {
+		return "", err
+	}
+
+	files, err := ListTemplateFiles(workRoot)
+	if err != nil {
+		return "", err
+	}
+	if err := SubstituteTemplateFiles(files, variables); err != nil {
+		return "", err
+	}
+
+	if err := RunCommandsGetError(workRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "-c", "user.name=" + Secret["computer-name"], "-c", "user.email=" + Secret["email"],
+			"-c", "commit.gpgsign=false", "commit", "-q", "--allow-empty", "-m", "Substitute " + ShortCommit(commit)}); err != nil {
+		return "", err
+	}
+	return GetHeadCommit(workRoot), nil
+}
+
+// TemplateSyncCommand applies the changes of the template repository since the recorded template commit to
+// the project as a three-way merge, on a new branch. The recorded template commit is updated. The changes
+// are committed unless there are conflicts.
+func TemplateSyncCommand(projectRoot string, noPull bool) error {
+	if !IsGitRepo(projectRoot) {
+		return NewError(102)
+	}
+
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+	base := config.TemplateCommit
+	if base == "" {
+		return NewError(172)
+	}
+
+	if changes, _, _ := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain"); strings.TrimSpace(changes) != "" {
+		return NewError(173)
+	}
+
+	templateRoot, err := PrepareTemplate(config.Template, noPull)
+	if err != nil {
+		return err
+	}
+	latest := GetHeadCommit(templateRoot)
+	if latest == "" {
+		return NewError(117, templateRoot)
+	}
+
+	ReportData("base", base)
+	ReportData("latest", latest)
+	if latest == base {
+		return Success("template-up-to-date", ShortCommit(base))
+	}
+
+	// The placeholders in the template are substituted in the project, so the changes are taken between the
+	// substituted commits, whose objects are fetched into the project for the three-way merge
+	workRoot := filepath.Join(projectRoot, TemplateWorkPath)
+	os.RemoveAll(workRoot)
+	defer os.RemoveAll(workRoot)
+	if err := os.MkdirAll(workRoot, 0755); err != nil {
+		return WrapError(err, 174)
+	}
+	if err := RunCommandGetError(projectRoot, "git", "clone", "-q", "--no-checkout", templateRoot, workRoot); err != nil {
+		return WrapError(err, 174)
+	}
+
+	substitutedBase, err := CommitSubstitutedTemplate(workRoot, base, "cmapi-base", config.TemplateVariables)
+	if err != nil {
+		return WrapError(err, 175, ShortCommit(base), ShortCommit(latest))
+	}
+	substitutedLatest, err := CommitSubstitutedTemplate(workRoot, latest, "cmapi-latest", config.TemplateVariables)
+	if err != nil {
+		return WrapError(err, 175, ShortCommit(base), ShortCommit(latest))
+	}
+	if err := RunCommandGetError(projectRoot, "git", "fetch", "-q", "--no-tags", workRoot, "cmapi-base", "cmapi-latest"); err != nil {
+		return WrapError(err, 174)
+	}
+
+	args := []string{"diff", "--binary", substitutedBase, substitutedLatest, "--", ".", ":(exclude)" + TemplateManifestName}
+	diff, _, code := RunCommandGetOutput(workRoot, "git", args...)
+	if code != 0 {
+		return WrapError(GetExitError(code, "git", args...), 175, ShortCommit(base), ShortCommit(latest))
+	}
+
+	branch := "template-sync-" + ShortCommit(latest)
+	if err := RunCommandGetError(projectRoot, "git", "checkout", "-b", branch); err != nil {
+		return WrapError(err, 176, branch)
+	}
+	ReportData("branch", branch)
+
+	if strings.TrimSpace(diff) != "" {
+		patchPath := filepath.Join(projectRoot, TemplatePatchPath)
+		if err := os.WriteFile(patchPath, []byte(diff), 0644); err != nil {
+			return WrapError(err, 177, branch)
+		}
+		defer os.Remove(patchPath)
+
+		if code := RunCommandGetStatus(projectRoot, "git", "apply", "--3way", TemplatePatchPath); code != 0 {
+			conflicts := GetConflictedFiles(projectRoot)
+			if len(conflicts) == 0 {
+				return WrapError(GetExitError(code, "git", "apply", "--3way", TemplatePatchPath), 177, branch)
+			}
+
+			// Record the new base anyway so that the next sync does not apply the same changes again
+			config.TemplateCommit = latest
+			if err := WriteProjectConfig(projectRoot, config); err != nil {
+				return WrapError(err, 177, branch)
+			}
+
+			ReportData("conflicts", conflicts)
+			for _, file := range conflicts {
+				fmt.Fprintln(Console, WarningText(T("template-conflict", file)))
+			}
+			return NewError(178, len(conflicts), branch)
+		}
+	}
+
+	config.TemplateCommit = latest
+	if err := WriteProjectConfig(projectRoot, config); err != nil {
+		return WrapError(err, 177, branch)
+	}
+
+	if err := RunCommandsGetError(projectRoot,
+		[]string{"git", "add", "-A"},
+		[]string{"git", "commit", "-m", "Sync template to " + ShortCommit(latest)}); err != nil {
+		return WrapError(err, 177, branch)
+	}
+
+	return Success("template-synced", ShortCommit(base), ShortCommit(latest), branch)
+}
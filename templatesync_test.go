@@ -0,0 +1,131 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// substituteTemplate returns the commands which check out the template at both commits with the variables
+// substituted and compare them
+func substituteTemplate(projectRoot string, templateRoot string, base string, latest string) []CommandSpec {
+	workRoot := filepath.Join(projectRoot, TemplateWorkPath)
+	commit := "git -c user.name=" + Secret["computer-name"] + " -c user.email=" + Secret["email"] +
+		" -c commit.gpgsign=false commit -q --allow-empty -m Substitute "
+	return []CommandSpec{
+		{"git clone -q --no-checkout " + templateRoot + " " + workRoot, "", "", 0},
+		{"git checkout -q -f -B cmapi-base " + base, "", "", 0},
+		{"git clean -q -f -d -x", "", "", 0},
+		{"git add -A", "", "", 0},
+		{commit + ShortCommit(base), "", "", 0},
+		{"git rev-parse HEAD", "3333333\n", "", 0},
+		{"git checkout -q -f -B cmapi-latest " + latest, "", "", 0},
+		{"git clean -q -f -d -x", "", "", 0},
+		{"git add -A", "", "", 0},
+		{commit + ShortCommit(latest), "", "", 0},
+		{"git rev-parse HEAD", "4444444\n", "", 0},
+		{"git fetch -q --no-tags " + workRoot + " cmapi-base cmapi-latest", "", "", 0},
+		{"git diff --binary 3333333 4444444 -- . :(exclude)cmapi-template.json", "diff --git a/src/A.cpp b/src/A.cpp\n", "", 0},
+	}
+}
+
+func TestTemplateSyncCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	AdminDir = t.TempDir()
+	defer func() { AdminDir = "" }()
+	templateRoot := filepath.Join(AdminDir, "cmapi-build")
+	os.MkdirAll(filepath.Join(templateRoot, ".git"), os.ModePerm)
+
+	projectRoot := t.TempDir()
+	os.MkdirAll(filepath.Join(projectRoot, ".git"), os.ModePerm)
+
+	base := "1111111aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"
+	latest := "2222222bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb"
+
+	// The project was not created from the template
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+	}
+	assert.Equal(t, 172, GetErrorCode(TemplateSyncCommand(projectRoot, true)))
+
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse HEAD", base + "\n", "", 0},
+	}
+	assert.Nil(t, RecordTemplate(projectRoot, templateRoot, "cmapi-build", map[string]string{"label": "A"}))
+	config := ReadProjectConfig(projectRoot)
+	assert.Equal(t, "cmapi-build", config.Template)
+	assert.Equal(t, base, config.TemplateCommit)
+
+	// Uncommitted changes
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", " M src/main.cpp\n", "", 0},
+	}
+	assert.Equal(t, 173, GetErrorCode(TemplateSyncCommand(projectRoot, true)))
+
+	// Up to date
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git rev-parse HEAD", base + "\n", "", 0},
+	}
+	assert.Nil(t, TemplateSyncCommand(projectRoot, true))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+
+	// Conflicts
+	MockCommandsQueue = append([]CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git rev-parse HEAD", latest + "\n", "", 0},
+	}, substituteTemplate(projectRoot, templateRoot, base, latest)...)
+	MockCommandsQueue = append(MockCommandsQueue, []CommandSpec{
+		{"git checkout -b template-sync-2222222", "", "", 0},
+		{"git apply --3way " + TemplatePatchPath, "", "", 1},
+		{"git diff --name-only --diff-filter=U", "src/main.cpp\n", "", 0},
+	}...)
+	assert.Equal(t, 178, GetErrorCode(TemplateSyncCommand(projectRoot, true)))
+	assert.Equal(t, latest, ReadProjectConfig(projectRoot).TemplateCommit)
+	_, err := os.Stat(filepath.Join(projectRoot, TemplatePatchPath))
+	assert.True(t, os.IsNotExist(err))
+	_, err = os.Stat(filepath.Join(projectRoot, TemplateWorkPath))
+	assert.True(t, os.IsNotExist(err))
+
+	// Merged cleanly
+	config.TemplateCommit = base
+	WriteProjectConfig(projectRoot, config)
+	MockCommandsQueue = append([]CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git rev-parse HEAD", latest + "\n", "", 0},
+	}, substituteTemplate(projectRoot, templateRoot, base, latest)...)
+	MockCommandsQueue = append(MockCommandsQueue, []CommandSpec{
+		{"git checkout -b template-sync-2222222", "", "", 0},
+		{"git apply --3way " + TemplatePatchPath, "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Sync template to 2222222", "", "", 0},
+	}...)
+	assert.Nil(t, TemplateSyncCommand(projectRoot, true))
+	assert.Equal(t, latest, ReadProjectConfig(projectRoot).TemplateCommit)
+	assert.Equal(t, 0, len(MockCommandsQueue))
+}
+
+func TestListTemplateFiles(t *testing.T) {
+	templateRoot := t.TempDir()
+	os.MkdirAll(filepath.Join(templateRoot, ".git", "objects"), os.ModePerm)
+	os.MkdirAll(filepath.Join(templateRoot, "src"), os.ModePerm)
+	os.WriteFile(filepath.Join(templateRoot, "src", "{{label}}.cpp"), []byte(""), 0644)
+
+	files, err := ListTemplateFiles(templateRoot)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(templateRoot, "src"), filepath.Join(templateRoot, "src", "{{label}}.cpp")}, files)
+
+	_, err = ListTemplateFiles(filepath.Join(templateRoot, "missing"))
+	assert.NotNil(t, err)
+}
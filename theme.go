@@ -0,0 +1,107 @@
+package main
+
+import (
+	"io"
+	"os"
+)
+
+// Style is the meaning of a piece of output, which decides its color
+type Style int
+
+const (
+	StyleInfo Style = iota
+	StyleHeader
+	StyleSuccess
+	StyleWarning
+	StyleError
+)
+
+// Theme is the ANSI escape code of each style
+var Theme = map[Style]string{
+	StyleInfo:    "\033[93m",   // yellow
+	StyleHeader:  "\033[1;93m", // bold yellow
+	StyleSuccess: "\033[92m",   // green
+	StyleWarning: "\033[95m",   // magenta
+	StyleError:   "\033[91m",   // red
+}
+
+// ColorMode is when to color the output, "auto", "always" or "never"
+var ColorMode = "auto"
+
+// SetColorMode sets when to color the output.
+// Returns false if the mode is unknown
+func SetColorMode(mode string) bool {
+	if mode != "auto" && mode != "always" && mode != "never" {
+		return false
+	}
+	ColorMode = mode
+	return true
+}
+
+// IsTerminal returns true if the writer is a terminal.
+// No side effect
+func IsTerminal(w io.Writer) bool {
+	file, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := file.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// IsColorEnabled returns true if the output to the console should be colored.
+// In the auto mode, the output is colored only if the console is a terminal, NO_COLOR is not set and
+// TERM is not dumb. See https://no-color.org
+// No side effect
+func IsColorEnabled() bool {
+	switch ColorMode {
+	case "always":
+		return true
+	case "never":
+		return false
+	}
+
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	return IsTerminal(Console)
+}
+
+// Paint returns the text in the color of the style, or the text as it is if the output is not colored.
+// No side effect
+func Paint(style Style, s string) string {
+	if !IsColorEnabled() {
+		return s
+	}
+	return Theme[style] + s + "\033[0m"
+}
+
+// Returns the text in the info style.
+// No side effect
+func InfoText(s string) string {
+	return Paint(StyleInfo, s)
+}
+
+// Returns the text in the section header style.
+// No side effect
+func HeaderText(s string) string {
+	return Paint(StyleHeader, s)
+}
+
+// Returns the text in the success style.
+// No side effect
+func SuccessText(s string) string {
+	return Paint(StyleSuccess, s)
+}
+
+// Returns the text in the warning style.
+// No side effect
+func WarningText(s string) string {
+	return Paint(StyleWarning, s)
+}
+
+// Returns the text in the error style.
+// No side effect
+func ErrorText(s string) string {
+	return Paint(StyleError, s)
+}
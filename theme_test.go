@@ -0,0 +1,67 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSetColorMode(t *testing.T) {
+	defer SetColorMode("auto")
+
+	assert.True(t, SetColorMode("never"))
+	assert.Equal(t, "never", ColorMode)
+	assert.False(t, SetColorMode("sometimes"))
+	assert.Equal(t, "never", ColorMode)
+}
+
+func TestIsColorEnabled(t *testing.T) {
+	console := Console
+	defer func() {
+		Console = console
+		SetColorMode("auto")
+	}()
+
+	// Not a terminal
+	Console = &bytes.Buffer{}
+	assert.False(t, IsTerminal(Console))
+	assert.False(t, IsColorEnabled())
+	assert.Equal(t, "done", SuccessText("done"))
+
+	SetColorMode("always")
+	assert.True(t, IsColorEnabled())
+	assert.Equal(t, "\033[92mdone\033[0m", SuccessText("done"))
+	assert.NotEqual(t, SuccessText("done"), ErrorText("done"))
+
+	SetColorMode("never")
+	assert.False(t, IsColorEnabled())
+	assert.Equal(t, "failed", ErrorText("failed"))
+
+	// A regular file is not a terminal
+	file, _ := os.CreateTemp(t.TempDir(), "log")
+	defer file.Close()
+	assert.False(t, IsTerminal(file))
+}
+
+func TestNoColor(t *testing.T) {
+	defer SetColorMode("auto")
+
+	t.Setenv("NO_COLOR", "1")
+	assert.False(t, IsColorEnabled())
+
+	t.Setenv("NO_COLOR", "")
+	t.Setenv("TERM", "dumb")
+	assert.False(t, IsColorEnabled())
+
+	SetColorMode("always")
+	assert.True(t, IsColorEnabled())
+}
+
+func TestHandleCommandColorOption(t *testing.T) {
+	assert.True(t, HandleCommand("help", []string{"--color=never", "help"}))
+	assert.Equal(t, "auto", ColorMode)
+
+	assert.False(t, HandleCommand("help", []string{"--color", "rainbow"}))
+}
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+)
+
+// ExtensionEditors are the names of the configuration directories of the editors which the PROS extension
+// can be installed in
+var ExtensionEditors = []string{"Code", "Code - Insiders", "VSCodium"}
+
+// GetPlatformSuffix returns the suffix of the directories installed by the PROS extension on this platform.
+// No side effect
+func GetPlatformSuffix() string {
+	if runtime.GOOS == "darwin" {
+		return "macos"
+	}
+	return runtime.GOOS
+}
+
+// GetExtensionInstallDirs returns the directory where the PROS extension installs PROS-CLI and the toolchain,
+// for each editor in the configuration directory.
+// No side effect
+func GetExtensionInstallDirs(configDir string) []string {
+	rtn := []string{}
+	for _, editor := range ExtensionEditors {
+		rtn = append(rtn, filepath.Join(configDir, editor, "User", "globalStorage", "sigbots.pros", "install"))
+	}
+	return rtn
+}
+
+// FindProsCli returns the directory of the PROS-CLI executable installed by the extension, or an empty string
+// if it is not found.
+// No side effect
+func FindProsCli(installDir string) string {
+	dir := filepath.Join(installDir, "pros-cli-"+GetPlatformSuffix())
+	info, err := os.Stat(filepath.Join(dir, GetExecutableName("pros")))
+	if err != nil || info.IsDir() {
+		return ""
+	}
+	return dir
+}
+
+// FindToolchain returns the PROS toolchain installed by the extension, or an empty string if it is not found.
+// No side effect
+func FindToolchain(installDir string) string {
+	dir := filepath.Join(installDir, "pros-toolchain-"+GetPlatformSuffix())
+	for _, candidate := range []string{filepath.Join(dir, "usr"), dir} {
+		if IsToolchainDir(candidate) {
+			return candidate
+		}
+	}
+	return ""
+}
+
+// DiscoverProsInstall returns the directory of PROS-CLI and the PROS toolchain installed by the extension in
+// any editor, or empty strings if they are not found.
+// No side effect
+func DiscoverProsInstall(configDir string) (string, string) {
+	cli, toolchain := "", ""
+	for _, installDir := range GetExtensionInstallDirs(configDir) {
+		if cli == "" {
+			cli = FindProsCli(installDir)
+		}
+		if toolchain == "" {
+			toolchain = FindToolchain(installDir)
+		}
+	}
+	return cli, toolchain
+}
+
+// PrependPath adds the directory to the beginning of PATH of this process.
+func PrependPath(dir string) {
+	os.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+}
+
+// SetupProsInstall uses PROS-CLI and the toolchain installed by the extension if they are not set up in the
+// environment. PATH and PROS_TOOLCHAIN of this process are changed, so all child processes inherit them.
+func SetupProsInstall() {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return
+	}
+	cli, toolchain := DiscoverProsInstall(configDir)
+
+	if _, err := exec.LookPath("pros"); err != nil && cli != "" {
+		PrependPath(cli)
+	}
+
+	current := os.Getenv("PROS_TOOLCHAIN")
+	if (current == "" || !IsToolchainDir(current)) && toolchain != "" {
+		os.Setenv("PROS_TOOLCHAIN", toolchain)
+		current = toolchain
+	}
+
+	if _, err := exec.LookPath(GetExecutableName("arm-none-eabi-gcc")); err != nil && current != "" &&
+		IsToolchainDir(current) {
+		PrependPath(filepath.Join(current, "bin"))
+	}
+}
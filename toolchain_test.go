@@ -0,0 +1,73 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createExtensionInstall creates the directories installed by the PROS extension of the editor
+func createExtensionInstall(configDir string, editor string, cli bool, toolchain bool) string {
+	installDir := filepath.Join(configDir, editor, "User", "globalStorage", "sigbots.pros", "install")
+	if cli {
+		dir := filepath.Join(installDir, "pros-cli-"+GetPlatformSuffix())
+		os.MkdirAll(dir, os.ModePerm)
+		os.WriteFile(filepath.Join(dir, GetExecutableName("pros")), []byte{}, 0755)
+	}
+	if toolchain {
+		dir := filepath.Join(installDir, "pros-toolchain-"+GetPlatformSuffix(), "usr", "bin")
+		os.MkdirAll(dir, os.ModePerm)
+		os.WriteFile(filepath.Join(dir, GetExecutableName("arm-none-eabi-gcc")), []byte{}, 0755)
+	}
+	return installDir
+}
+
+func TestDiscoverProsInstall(t *testing.T) {
+	configDir := t.TempDir()
+
+	cli, toolchain := DiscoverProsInstall(configDir)
+	assert.Equal(t, "", cli)
+	assert.Equal(t, "", toolchain)
+
+	codium := createExtensionInstall(configDir, "VSCodium", true, true)
+	insiders := createExtensionInstall(configDir, "Code - Insiders", false, true)
+
+	cli, toolchain = DiscoverProsInstall(configDir)
+	assert.Equal(t, filepath.Join(codium, "pros-cli-"+GetPlatformSuffix()), cli)
+	assert.Equal(t, filepath.Join(insiders, "pros-toolchain-"+GetPlatformSuffix(), "usr"), toolchain)
+}
+
+func TestSetupProsInstall(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the configuration directory is only overridden on Linux")
+	}
+
+	configDir := t.TempDir()
+	emptyDir := t.TempDir()
+	t.Setenv("XDG_CONFIG_HOME", configDir)
+	t.Setenv("PATH", emptyDir)
+	t.Setenv("PROS_TOOLCHAIN", "")
+
+	installDir := createExtensionInstall(configDir, "Code", true, true)
+	toolchain := filepath.Join(installDir, "pros-toolchain-linux", "usr")
+
+	SetupProsInstall()
+	assert.Equal(t, toolchain, os.Getenv("PROS_TOOLCHAIN"))
+	assert.Equal(t, filepath.Join(toolchain, "bin")+":"+filepath.Join(installDir, "pros-cli-linux")+":"+emptyDir,
+		os.Getenv("PATH"))
+
+	// Nothing changes if they are set up already
+	SetupProsInstall()
+	assert.Equal(t, toolchain, os.Getenv("PROS_TOOLCHAIN"))
+	assert.Equal(t, filepath.Join(toolchain, "bin")+":"+filepath.Join(installDir, "pros-cli-linux")+":"+emptyDir,
+		os.Getenv("PATH"))
+
+	// A valid PROS_TOOLCHAIN is kept
+	other := createExtensionInstall(t.TempDir(), "Code", false, true)
+	t.Setenv("PROS_TOOLCHAIN", filepath.Join(other, "pros-toolchain-linux", "usr"))
+	SetupProsInstall()
+	assert.Equal(t, filepath.Join(other, "pros-toolchain-linux", "usr"), os.Getenv("PROS_TOOLCHAIN"))
+}
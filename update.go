@@ -0,0 +1,227 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"time"
+)
+
+// Release is a release of this tool on GitHub
+type Release struct {
+	TagName string         `json:"tag_name"`
+	Assets  []ReleaseAsset `json:"assets"`
+}
+
+// ReleaseAsset is a file attached to a release
+type ReleaseAsset struct {
+	Name string `json:"name"`
+	Url  string `json:"browser_download_url"`
+}
+
+// ChecksumsAssetName is the name of the file with the SHA-256 checksums of the other assets, in the format
+// of sha256sum
+const ChecksumsAssetName = "SHA256SUMS"
+
+// GithubReleaseUrl is the API endpoint of the latest release of this tool
+var GithubReleaseUrl = "https://api.github.com/repos/Jerrylum/cmapi-cli/releases/latest"
+
+// GetReleaseAssetName returns the name of the executable built for the platform in the release workflow.
+// No side effect
+func GetReleaseAssetName(goos string, goarch string) string {
+	name := "cmapi-cli-" + goos + "-" + goarch
+	if goos == "windows" {
+		name += ".exe"
+	}
+	return name
+}
+
+// GetAsset returns the asset with the given name, or nil if not found.
+// No side effect
+func (r *Release) GetAsset(name string) *ReleaseAsset {
+	for i := range r.Assets {
+		if r.Assets[i].Name == name {
+			return &r.Assets[i]
+		}
+	}
+	return nil
+}
+
+// GetVersion returns the version of the release without the leading 'v'.
+// No side effect
+func (r *Release) GetVersion() string {
+	return strings.TrimPrefix(r.TagName, "v")
+}
+
+// Download returns the content of the URL
+func Download(url string) ([]byte, error) {
+	client := &http.Client{Timeout: 5 * time.Minute}
+	res, err := client.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode != http.StatusOK {
+		return nil, errors.New("unexpected status " + res.Status)
+	}
+	return io.ReadAll(res.Body)
+}
+
+// FetchLatestRelease returns the latest release from GithubReleaseUrl
+func FetchLatestRelease() (*Release, error) {
+	data, err := Download(GithubReleaseUrl)
+	if err != nil {
+		return nil, err
+	}
+
+	var release Release
+	if err := json.Unmarshal(data, &release); err != nil {
+		return nil, err
+	}
+	if release.TagName == "" {
+		return nil, errors.New("no tag name in the release")
+	}
+	return &release, nil
+}
+
+// ParseChecksums returns the checksums in the output of sha256sum, keyed by file name.
+// No side effect
+func ParseChecksums(data []byte) map[string]string {
+	rtn := map[string]string{}
+	scanner := bufio.NewScanner(bytes.NewReader(data))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 2 {
+			// The name is prefixed with '*' in binary mode
+			rtn[strings.TrimPrefix(fields[1], "*")] = strings.ToLower(fields[0])
+		}
+	}
+	return rtn
+}
+
+// ReplaceExecutable replaces the executable with the data atomically. The new file is written next to it
+// and renamed over it. On Windows, the running executable is moved to "<path>.old" first because it cannot
+// be overwritten, and it is moved back if the new file cannot take its place.
+func ReplaceExecutable(path string, data []byte) error {
+	file, err := os.CreateTemp(filepath.Dir(path), "."+filepath.Base(path)+".new-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(file.Name()) // no effect after the rename
+
+	if _, err := file.Write(data); err != nil {
+		file.Close()
+		return err
+	}
+	if err := file.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(file.Name(), 0755); err != nil {
+		return err
+	}
+
+	old := ""
+	if runtime.GOOS == "windows" {
+		old = path + ".old"
+		os.Remove(old)
+		if err := os.Rename(path, old); err != nil {
+			return err
+		}
+	}
+
+	if err := os.Rename(file.Name(), path); err != nil {
+		if old != "" {
+			os.Rename(old, path)
+		}
+		return err
+	}
+	return nil
+}
+
+// InstallRelease downloads the executable for this platform in the release, verifies its checksum and
+// replaces the executable at the path with it
+func InstallRelease(release *Release, path string) error {
+	name := GetReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+	asset := release.GetAsset(name)
+	if asset == nil {
+		return NewError(163, name)
+	}
+	checksumsAsset := release.GetAsset(ChecksumsAssetName)
+	if checksumsAsset == nil {
+		return NewError(165, name)
+	}
+
+	checksumsData, err := Download(checksumsAsset.Url)
+	if err != nil {
+		return WrapError(err, 164, ChecksumsAssetName)
+	}
+	expected, ok := ParseChecksums(checksumsData)[name]
+	if !ok {
+		return NewError(165, name)
+	}
+
+	fmt.Fprintln(Console, InfoText(T("update-downloading", asset.Url)))
+	data, err := Download(asset.Url)
+	if err != nil {
+		return WrapError(err, 164, name)
+	}
+	sum := sha256.Sum256(data)
+	if hex.EncodeToString(sum[:]) != expected {
+		return NewError(166, name)
+	}
+
+	if err := ReplaceExecutable(path, data); err != nil {
+		return WrapError(err, 167, path)
+	}
+	return nil
+}
+
+// UpdateCommand checks the latest release and installs it if it is newer than this version, unless the
+// action is "check"
+func UpdateCommand(action string) error {
+	if action != "" && action != "check" {
+		return NewError(201, action)
+	}
+
+	release, err := FetchLatestRelease()
+	if err != nil {
+		return WrapError(err, 162)
+	}
+
+	latest := release.GetVersion()
+	ReportData("current", Version)
+	ReportData("latest", latest)
+
+	if CompareVersions(latest, Version) <= 0 {
+		return Success("update-latest", Version)
+	}
+	if action == "check" {
+		return Success("update-available", latest, Version)
+	}
+
+	path, err := os.Executable()
+	if err == nil {
+		path, err = filepath.EvalSymlinks(path)
+	}
+	if err != nil {
+		return WrapError(err, 167, path)
+	}
+
+	if err := InstallRelease(release, path); err != nil {
+		return err
+	}
+
+	ReportData("path", path)
+	return Success("updated", Version, latest)
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// newReleaseServer returns a server of the latest release with the given tag, the executable for this
+// platform and the checksums
+func newReleaseServer(tag string, executable []byte, checksums string) *httptest.Server {
+	name := GetReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/releases/latest":
+			w.Write([]byte(`{"tag_name": "` + tag + `", "assets": [` +
+				`{"name": "` + name + `", "browser_download_url": "` + server.URL + `/download/` + name + `"},` +
+				`{"name": "SHA256SUMS", "browser_download_url": "` + server.URL + `/download/SHA256SUMS"}]}`))
+		case "/download/" + name:
+			w.Write(executable)
+		case "/download/SHA256SUMS":
+			w.Write([]byte(checksums))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	return server
+}
+
+func TestGetReleaseAssetName(t *testing.T) {
+	assert.Equal(t, "cmapi-cli-linux-amd64", GetReleaseAssetName("linux", "amd64"))
+	assert.Equal(t, "cmapi-cli-darwin-arm64", GetReleaseAssetName("darwin", "arm64"))
+	assert.Equal(t, "cmapi-cli-windows-amd64.exe", GetReleaseAssetName("windows", "amd64"))
+}
+
+func TestParseChecksums(t *testing.T) {
+	checksums := ParseChecksums([]byte("ABCD  cmapi-cli-linux-amd64\nef01 *cmapi-cli-windows-amd64.exe\n\ninvalid\n"))
+	assert.Equal(t, map[string]string{
+		"cmapi-cli-linux-amd64":       "abcd",
+		"cmapi-cli-windows-amd64.exe": "ef01",
+	}, checksums)
+}
+
+func TestInstallRelease(t *testing.T) {
+	name := GetReleaseAssetName(runtime.GOOS, runtime.GOARCH)
+	executable := []byte("new version")
+	sum := sha256.Sum256(executable)
+
+	server := newReleaseServer("v9.0.0", executable, hex.EncodeToString(sum[:])+"  "+name+"\n")
+	defer server.Close()
+
+	original := GithubReleaseUrl
+	GithubReleaseUrl = server.URL + "/releases/latest"
+	defer func() { GithubReleaseUrl = original }()
+
+	release, err := FetchLatestRelease()
+	assert.Nil(t, err)
+	assert.Equal(t, "9.0.0", release.GetVersion())
+
+	path := filepath.Join(t.TempDir(), "cmapi-cli")
+	os.WriteFile(path, []byte("old version"), 0755)
+
+	assert.Nil(t, InstallRelease(release, path))
+	data, _ := os.ReadFile(path)
+	assert.Equal(t, "new version", string(data))
+	info, _ := os.Stat(path)
+	if runtime.GOOS != "windows" {
+		assert.Equal(t, os.FileMode(0755), info.Mode().Perm())
+	}
+
+	// The checksum does not match
+	os.WriteFile(path, []byte("old version"), 0755)
+	release.Assets[0].Url = server.URL + "/download/SHA256SUMS"
+	assert.Equal(t, 166, GetErrorCode(InstallRelease(release, path)))
+	data, _ = os.ReadFile(path)
+	assert.Equal(t, "old version", string(data))
+
+	// The asset does not exist
+	release.Assets = release.Assets[1:]
+	assert.Equal(t, 163, GetErrorCode(InstallRelease(release, path)))
+
+	// No temporary files are left
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestReplaceExecutable(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("a directory can be moved away on Windows")
+	}
+
+	// The new file cannot take the place of a directory
+	path := filepath.Join(t.TempDir(), "cmapi-cli")
+	os.MkdirAll(filepath.Join(path, "bin"), 0755)
+
+	assert.NotNil(t, ReplaceExecutable(path, []byte("new version")))
+	assert.DirExists(t, filepath.Join(path, "bin"))
+	entries, _ := os.ReadDir(filepath.Dir(path))
+	assert.Equal(t, 1, len(entries))
+}
+
+func TestUpdateCommand(t *testing.T) {
+	server := newReleaseServer("v"+Version, []byte{}, "")
+	defer server.Close()
+
+	original := GithubReleaseUrl
+	GithubReleaseUrl = server.URL + "/releases/latest"
+	defer func() { GithubReleaseUrl = original }()
+
+	assert.Nil(t, UpdateCommand(""))
+	assert.Equal(t, 201, GetErrorCode(UpdateCommand("install")))
+
+	server.Close()
+	assert.Equal(t, 162, GetErrorCode(UpdateCommand("check")))
+
+	newer := newReleaseServer("v99.0.0", []byte{}, "")
+	defer newer.Close()
+	GithubReleaseUrl = newer.URL + "/releases/latest"
+	assert.Nil(t, UpdateCommand("check"))
+}
@@ -0,0 +1,161 @@
+package main
+
+import (
+	"fmt"
+	"path"
+	"regexp"
+	"strings"
+)
+
+// BuildVariant is a build of the project with extra compile-time defines, uploaded to its own slot.
+type BuildVariant struct {
+	Name        string   `json:"name"`
+	Defines     []string `json:"defines,omitempty"`
+	CFlags      string   `json:"cflags,omitempty"`
+	Slot        int      `json:"slot"`
+	ProgramName string   `json:"program-name,omitempty"`
+}
+
+// Returns true if the given variant name is valid
+// No side effect
+var IsValidVariantName = regexp.MustCompile(`^[A-Za-z0-9_\-]+$`).MatchString
+
+// GetVariantOutputDir returns the output directory of the variant, relative to the project root.
+// No side effect
+func GetVariantOutputDir(variant BuildVariant) string {
+	return path.Join("bin", "variants", variant.Name)
+}
+
+// GetVariantProgramName returns the program name shown on the brain. The variant name is used by default.
+// No side effect
+func GetVariantProgramName(variant BuildVariant) string {
+	if variant.ProgramName != "" {
+		return variant.ProgramName
+	}
+	return variant.Name
+}
+
+// GetVariantFlags returns the extra compiler flags of the variant.
+// No side effect
+func GetVariantFlags(variant BuildVariant) string {
+	flags := []string{}
+	for _, define := range variant.Defines {
+		flags = append(flags, "-D"+define)
+	}
+	if cflags := strings.TrimSpace(variant.CFlags); cflags != "" {
+		flags = append(flags, cflags)
+	}
+	return strings.Join(flags, " ")
+}
+
+// GetVariantMakeArgs returns the make arguments to build the variant into its own output directory.
+// The variant is linked as a monolith binary so that it can be uploaded from any directory.
+// No side effect
+func GetVariantMakeArgs(variant BuildVariant) []string {
+	extra := GetVariantFlags(variant)
+
+	return []string{
+		"-j",
+		"BINDIR=" + GetVariantOutputDir(variant),
+		"USE_PACKAGE=0",
+		"EXTRA_CFLAGS=" + extra,
+		"EXTRA_CXXFLAGS=" + extra,
+	}
+}
+
+// SelectVariants returns the variants with the given names, or all variants if no names are given.
+// The second return value is the first name not found, if any.
+// No side effect
+func SelectVariants(variants []BuildVariant, names []string) ([]BuildVariant, string) {
+	if len(names) == 0 {
+		return variants, ""
+	}
+
+	rtn := []BuildVariant{}
+	for _, name := range names {
+		found := false
+		for _, variant := range variants {
+			if variant.Name == name {
+				rtn = append(rtn, variant)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, name
+		}
+	}
+
+	return rtn, ""
+}
+
+// VariantsCommand lists, builds or uploads the build variants declared in the project config
+func VariantsCommand(projectRoot string, action string, names []string) error {
+	if !IsProsProject(projectRoot) {
+		return NewError(134)
+	}
+
+	config := ReadProjectConfig(projectRoot)
+	if config == nil {
+		return NewError(135)
+	}
+
+	if len(config.Variants) == 0 {
+		return NewError(136)
+	}
+
+	if action == "" || action == "list" {
+		ReportData("variants", config.Variants)
+		fmt.Fprintln(Console, HeaderText(T("variants-listing")))
+		for _, variant := range config.Variants {
+			fmt.Fprintln(Console, InfoText(variant.Name+": ")+T("variant-detail",
+				variant.Slot, GetVariantProgramName(variant), GetVariantFlags(variant)))
+		}
+		return nil
+	}
+
+	if action != "build" && action != "upload" {
+		return NewError(201, action)
+	}
+
+	variants, missing := SelectVariants(config.Variants, names)
+	if missing != "" {
+		return NewError(137, missing)
+	}
+
+	for _, variant := range variants {
+		if !IsValidVariantName(variant.Name) {
+			return NewError(138, variant.Name)
+		}
+		if variant.Slot < 1 || variant.Slot > 8 {
+			return NewError(139, variant.Name, variant.Slot)
+		}
+	}
+
+	for _, variant := range variants {
+		fmt.Fprintln(Console, HeaderText("-------------- Make Variant '"+variant.Name+"' --------------"))
+
+		if err := RunCommandGetError(projectRoot, "make", GetVariantMakeArgs(variant)...); err != nil {
+			BeepFail()
+			return WrapError(err, 140, variant.Name)
+		}
+	}
+
+	if action == "build" {
+		BeepSuccess()
+		return Success("variants-built", len(variants))
+	}
+
+	for _, variant := range variants {
+		fmt.Fprintln(Console, InfoText(T("variants-uploading", variant.Name, variant.Slot)))
+
+		binary := path.Join(GetVariantOutputDir(variant), "monolith.bin")
+		if code, _ := UploadProgram(projectRoot, variant.Slot, "--name", GetVariantProgramName(variant), binary); code != 0 {
+			BeepFail()
+			return WrapError(GetExitError(code, "pros", "upload"), 141, variant.Name)
+		}
+	}
+
+	BeepSuccess()
+	return Success("variants-upload", len(variants))
+}
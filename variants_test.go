@@ -0,0 +1,78 @@
+package main
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestGetVariantMakeArgs(t *testing.T) {
+	variant := BuildVariant{Name: "left", Defines: []string{"AUTON_LEFT", "ROUTE=2"}, CFlags: " -O2 ", Slot: 2}
+
+	assert.Equal(t, "-DAUTON_LEFT -DROUTE=2 -O2", GetVariantFlags(variant))
+	assert.Equal(t, []string{
+		"-j",
+		"BINDIR=bin/variants/left",
+		"USE_PACKAGE=0",
+		"EXTRA_CFLAGS=-DAUTON_LEFT -DROUTE=2 -O2",
+		"EXTRA_CXXFLAGS=-DAUTON_LEFT -DROUTE=2 -O2",
+	}, GetVariantMakeArgs(variant))
+	assert.Equal(t, "left", GetVariantProgramName(variant))
+}
+
+func TestSelectVariants(t *testing.T) {
+	variants := []BuildVariant{{Name: "left"}, {Name: "right"}, {Name: "skills"}}
+
+	selected, missing := SelectVariants(variants, nil)
+	assert.Equal(t, variants, selected)
+	assert.Equal(t, "", missing)
+
+	selected, missing = SelectVariants(variants, []string{"skills", "left"})
+	assert.Equal(t, []BuildVariant{{Name: "skills"}, {Name: "left"}}, selected)
+	assert.Equal(t, "", missing)
+
+	selected, missing = SelectVariants(variants, []string{"left", "center"})
+	assert.Nil(t, selected)
+	assert.Equal(t, "center", missing)
+}
+
+func TestVariantsCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	wd, _ := os.Getwd()
+
+	// not a PROS project
+	assert.Equal(t, 134, GetErrorCode(VariantsCommand(wd, "build", nil)))
+
+	os.WriteFile("project.pros", []byte("any"), 0644)
+
+	// no variants declared
+	assert.Equal(t, 136, GetErrorCode(VariantsCommand(wd, "build", nil)))
+
+	WriteProjectConfig(wd, &ProjectConfig{Variants: []BuildVariant{
+		{Name: "left", Defines: []string{"AUTON_LEFT"}, Slot: 1},
+		{Name: "right", Defines: []string{"AUTON_RIGHT"}, Slot: 2, ProgramName: "Right Side"},
+	}})
+
+	MockCommandsQueue = []CommandSpec{
+		// build all
+		{"make -j BINDIR=bin/variants/left USE_PACKAGE=0 EXTRA_CFLAGS=-DAUTON_LEFT EXTRA_CXXFLAGS=-DAUTON_LEFT", "", "", 0},
+		{"make -j BINDIR=bin/variants/right USE_PACKAGE=0 EXTRA_CFLAGS=-DAUTON_RIGHT EXTRA_CXXFLAGS=-DAUTON_RIGHT", "", "", 0},
+		// fail to make
+		{"make -j BINDIR=bin/variants/right USE_PACKAGE=0 EXTRA_CFLAGS=-DAUTON_RIGHT EXTRA_CXXFLAGS=-DAUTON_RIGHT", "", "", 2},
+		// upload one
+		{"make -j BINDIR=bin/variants/right USE_PACKAGE=0 EXTRA_CFLAGS=-DAUTON_RIGHT EXTRA_CXXFLAGS=-DAUTON_RIGHT", "", "", 0},
+		{"pros lsusb --target v5", "VEX EDR V5 System Port - ttyACM0", "", 0},
+		{"pros upload --after screen --slot 2 --name Right Side bin/variants/right/monolith.bin", "", "", 0},
+	}
+
+	assert.Nil(t, VariantsCommand(wd, "list", nil))
+	assert.Nil(t, VariantsCommand(wd, "build", nil))
+	assert.Equal(t, 140, GetErrorCode(VariantsCommand(wd, "build", []string{"right"})))
+	assert.Nil(t, VariantsCommand(wd, "upload", []string{"right"}))
+	assert.Equal(t, 137, GetErrorCode(VariantsCommand(wd, "build", []string{"center"})))
+	assert.Equal(t, 201, GetErrorCode(VariantsCommand(wd, "deploy", nil)))
+	assert.Empty(t, MockCommandsQueue)
+}
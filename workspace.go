@@ -0,0 +1,198 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+)
+
+// WorkspaceResult is the result of an action on a project in the workspace directory
+type WorkspaceResult struct {
+	Label     string  `json:"label"`
+	Path      string  `json:"path"`
+	Success   bool    `json:"success"`
+	ErrorCode int     `json:"error-code,omitempty"`
+	Detail    string  `json:"detail,omitempty"`
+	Seconds   float64 `json:"seconds"`
+}
+
+// WorkspaceConcurrency is the number of projects the workspace command runs on at the same time
+var WorkspaceConcurrency = 4
+
+// ProjectOutputs are the writers which take the place of the console and stderr for the commands run in
+// the projects, keyed by project root, while the workspace command runs on them concurrently
+var ProjectOutputs = map[string]io.Writer{}
+
+// ProjectOutputsLock is the lock of ProjectOutputs
+var ProjectOutputsLock sync.Mutex
+
+// GetCommandOutput returns the writers for the output and the errors of a command run in the directory.
+// No side effect
+func GetCommandOutput(workingDir string) (io.Writer, io.Writer) {
+	ProjectOutputsLock.Lock()
+	defer ProjectOutputsLock.Unlock()
+
+	for projectRoot, w := range ProjectOutputs {
+		if rel, err := filepath.Rel(projectRoot, workingDir); err == nil && !strings.HasPrefix(rel, "..") {
+			return w, w
+		}
+	}
+	return Console, os.Stderr
+}
+
+// SetProjectOutput sends the output of the commands run in the project to the writer, or back to the
+// console if the writer is nil
+func SetProjectOutput(projectRoot string, w io.Writer) {
+	ProjectOutputsLock.Lock()
+	defer ProjectOutputsLock.Unlock()
+
+	if w == nil {
+		delete(ProjectOutputs, projectRoot)
+	} else {
+		ProjectOutputs[projectRoot] = w
+	}
+}
+
+// ListWorkspaceRepos returns the root of every Git repository in the workspace directory, sorted by name.
+// No side effect
+func ListWorkspaceRepos(workspaceDir string) ([]string, error) {
+	entries, err := os.ReadDir(workspaceDir)
+	if err != nil {
+		return nil, err
+	}
+
+	rtn := []string{}
+	for _, entry := range entries {
+		projectRoot := filepath.Join(workspaceDir, entry.Name())
+		if _, err := os.Stat(filepath.Join(projectRoot, ".git")); entry.IsDir() && err == nil {
+			rtn = append(rtn, projectRoot)
+		}
+	}
+	return rtn, nil
+}
+
+// GetWorkspaceAction returns the function which runs the action on a project and returns the detail of the
+// result, or nil if the action is unknown.
+// No side effect
+func GetWorkspaceAction(action string) func(projectRoot string) (string, error) {
+	switch action {
+	case "pull":
+		return func(projectRoot string) (string, error) {
+			return "", PullCommand(projectRoot)
+		}
+	case "backup":
+		return func(projectRoot string) (string, error) {
+			status, _, _ := RunCommandGetOutput(projectRoot, "git", "status", "--porcelain=v2", "--branch")
+			_, changes, ahead, _ := ParseBranchStatus(status)
+			if changes == 0 && ahead == 0 {
+				return T("workspace-nothing"), nil
+			} else if changes == 0 {
+				// Nothing to commit, only the commits are pushed, unless the hook changes something
+				if err := RunPreHooks(projectRoot, "pre-backup", "backup"); err != nil {
+					return "", err
+				}
+				if err := CommitChangedFiles(projectRoot); err != nil {
+					return "", err
+				}
+				if err := RunCommandGetError(projectRoot, "git", "push", "-u", "origin", "master"); err != nil {
+					return "", WrapError(err, 106)
+				}
+				return T("workspace-pushed", ahead), nil
+			}
+			return "", BackupCommand(projectRoot)
+		}
+	case "status":
+		return func(projectRoot string) (string, error) {
+			summary, _ := GetProjectSummary(projectRoot, nil)
+			detail := summary.Branch + ", " + GetProjectState(summary)
+			if GetKernelWarning(projectRoot) != "" {
+				detail += ", " + T("workspace-kernel-mismatch")
+			}
+			return detail, nil
+		}
+	default:
+		return nil
+	}
+}
+
+// WorkspaceCommand runs the action on every Git repository in the workspace directory concurrently. A failed
+// project does not stop the others. The output of each project is kept and printed only if the project
+// fails, a line is printed when a project finishes and a table of all results is printed at the end.
+func WorkspaceCommand(workspaceDir string, action string) error {
+	run := GetWorkspaceAction(action)
+	if run == nil {
+		return NewError(201, action)
+	}
+
+	repos, err := ListWorkspaceRepos(workspaceDir)
+	if err != nil {
+		return WrapError(err, 168, workspaceDir)
+	}
+	if len(repos) == 0 {
+		return Success("projects-empty", workspaceDir)
+	}
+
+	// The projects must not print to the console or report to the result of this command at the same time
+	console, result := Console, CurrentResult
+	Console, CurrentResult = io.Discard, nil
+
+	results := make([]WorkspaceResult, len(repos))
+	var lock sync.Mutex
+	ForEachConcurrently(WorkspaceConcurrency, len(repos), func(i int) {
+		var output bytes.Buffer
+		SetProjectOutput(repos[i], &output)
+		defer SetProjectOutput(repos[i], nil)
+
+		start := time.Now()
+		detail, err := run(repos[i])
+
+		results[i] = WorkspaceResult{
+			Label:   GetProjectLabel(repos[i]),
+			Path:    repos[i],
+			Success: err == nil,
+			Detail:  detail,
+			Seconds: time.Since(start).Seconds(),
+		}
+		if err != nil {
+			results[i].ErrorCode = GetErrorCode(err)
+			results[i].Detail = err.Error()
+		}
+
+		lock.Lock()
+		defer lock.Unlock()
+		fmt.Fprintln(console, T("workspace-finished", action, results[i].Label))
+		if text := strings.TrimSpace(output.String()); err != nil && text != "" {
+			fmt.Fprintln(console, IndentLines(text, "    | "))
+		}
+	})
+
+	Console, CurrentResult = console, result
+	ReportData("action", action)
+	ReportData("results", results)
+
+	failed := 0
+	format := "%-20s %-7s %7s  %s"
+	fmt.Fprintln(Console)
+	fmt.Fprintln(Console, HeaderText(fmt.Sprintf(format, T("workspace-label"), T("workspace-result"),
+		T("workspace-time"), T("workspace-detail"))))
+	for _, result := range results {
+		seconds := fmt.Sprintf("%.1fs", result.Seconds)
+		if result.Success {
+			fmt.Fprintln(Console, SuccessText(fmt.Sprintf(format, result.Label, T("workspace-ok"), seconds, result.Detail)))
+		} else {
+			failed++
+			detail := strings.ReplaceAll(result.Detail, "\n", " ")
+			fmt.Fprintln(Console, ErrorText(fmt.Sprintf(format, result.Label, T("workspace-failed"), seconds, detail)))
+		}
+	}
+
+	if failed != 0 {
+		return NewError(169, failed, len(results))
+	}
+	return Success("workspace-done", len(results))
+}
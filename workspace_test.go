@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// createWorkspace creates a workspace directory with the Git repositories and a directory which is not
+func createWorkspace(t *testing.T, names ...string) string {
+	workspace := t.TempDir()
+	for _, name := range names {
+		os.MkdirAll(filepath.Join(workspace, name, ".git"), os.ModePerm)
+	}
+	os.MkdirAll(filepath.Join(workspace, "notes"), os.ModePerm)
+	return workspace
+}
+
+func TestListWorkspaceRepos(t *testing.T) {
+	workspace := createWorkspace(t, "7984-B", "7984-A")
+	repos, err := ListWorkspaceRepos(workspace)
+	assert.Nil(t, err)
+	assert.Equal(t, []string{filepath.Join(workspace, "7984-A"), filepath.Join(workspace, "7984-B")}, repos)
+
+	_, err = ListWorkspaceRepos(filepath.Join(workspace, "missing"))
+	assert.NotNil(t, err)
+}
+
+func TestForEachConcurrently(t *testing.T) {
+	results := make([]int, 100)
+	ForEachConcurrently(4, len(results), func(i int) {
+		results[i] = i * i
+	})
+	for i, result := range results {
+		assert.Equal(t, i*i, result)
+	}
+
+	ForEachConcurrently(4, 0, func(i int) {
+		t.Fail()
+	})
+}
+
+func TestWorkspaceCommand(t *testing.T) {
+	setup()
+	defer teardown()
+
+	original := WorkspaceConcurrency
+	WorkspaceConcurrency = 1 // the mocked commands run in order
+	defer func() { WorkspaceConcurrency = original }()
+
+	workspace := createWorkspace(t, "7984-A", "7984-B", "7984-C")
+
+	// One failure does not stop the others
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git pull", "Already up to date.", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "conflict", 1},
+		{"git rev-parse", "", "", 0},
+		{"git pull", "", "", 0},
+	}
+	var output bytes.Buffer
+	console := Console
+	Console = &output
+	assert.Equal(t, 169, GetErrorCode(WorkspaceCommand(workspace, "pull")))
+	Console = console
+	assert.Equal(t, 0, len(MockCommandsQueue))
+	assert.Equal(t, 0, len(ProjectOutputs))
+
+	// Only the output of the failed project is printed
+	assert.Contains(t, output.String(), "    | conflict")
+	assert.NotContains(t, output.String(), "Already up to date.")
+
+	MockCommandsQueue = []CommandSpec{
+		{"git status --porcelain=v2 --branch", "# branch.head master\n", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head master\n? a.txt\n", "", 0},
+		{"git rev-parse", "", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 0},
+		{"git push -u origin master", "", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head master\n# branch.ab +2 -0\n", "", 0},
+		{"git status --porcelain", "", "", 0},
+		{"git push -u origin master", "", "", 0},
+	}
+	assert.Nil(t, WorkspaceCommand(workspace, "backup"))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+
+	// The pre-backup hook runs before the commits are pushed, and the files it changes are committed
+	os.MkdirAll(filepath.Join(workspace, "7984-A", ".cmapi"), os.ModePerm)
+	os.WriteFile(filepath.Join(workspace, "7984-A", ".cmapi", "config.json"),
+		[]byte(`{"hooks": {"pre-backup": "clang-format -i src/main.cpp"}}`), 0644)
+	MockCommandsQueue = []CommandSpec{
+		{"git status --porcelain=v2 --branch", "# branch.head master\n# branch.ab +1 -0\n", "", 0},
+		{strings.Join(GetShellCommand("clang-format -i src/main.cpp"), " "), "", "", 0},
+		{"git status --porcelain", " M src/main.cpp\n", "", 0},
+		{"git add -A", "", "", 0},
+		{"git commit -m Backup", "", "", 0},
+		{"git push -u origin master", "", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head master\n", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head master\n", "", 0},
+	}
+	assert.Nil(t, WorkspaceCommand(workspace, "backup"))
+	assert.Equal(t, 0, len(MockCommandsQueue))
+
+	assert.Equal(t, 201, GetErrorCode(WorkspaceCommand(workspace, "push")))
+	assert.Nil(t, WorkspaceCommand(t.TempDir(), "pull"))
+	assert.Equal(t, 168, GetErrorCode(WorkspaceCommand(filepath.Join(workspace, "missing"), "pull")))
+}
+
+func TestWorkspaceCommandResult(t *testing.T) {
+	setup()
+	defer teardown()
+
+	original := WorkspaceConcurrency
+	WorkspaceConcurrency = 1
+	defer func() { WorkspaceConcurrency = original }()
+
+	workspace := createWorkspace(t, "7984-A")
+
+	MockCommandsQueue = []CommandSpec{
+		{"git rev-parse", "", "", 0},
+		{"git status --porcelain=v2 --branch", "# branch.head dev\n# branch.ab +1 -0\n", "", 0},
+		{"git log -1 --format=%cI", "", "", 128},
+	}
+
+	BeginCommandResult("workspace", []string{"status"})
+	assert.Nil(t, WorkspaceCommand(workspace, "status"))
+	results := CurrentResult.Data["results"].([]WorkspaceResult)
+	CurrentResult = nil
+
+	assert.Equal(t, 1, len(results))
+	assert.Equal(t, "A", results[0].Label)
+	assert.True(t, results[0].Success)
+	assert.Equal(t, "dev, +1", results[0].Detail)
+}
+
+func TestWorkspaceCommandConcurrent(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is not installed")
+	}
+
+	// Real repositories, the commands of the projects run at the same time in any order
+	workspace := t.TempDir()
+	labels := []string{"A", "B", "C", "D", "E", "F", "G", "H"}
+	for i, label := range labels {
+		projectRoot := filepath.Join(workspace, "7984-"+label)
+		os.MkdirAll(projectRoot, os.ModePerm)
+		assert.Nil(t, exec.Command("git", "init", "-q", "-b", "dev-"+label, projectRoot).Run())
+		for j := 0; j < i; j++ {
+			os.WriteFile(filepath.Join(projectRoot, fmt.Sprintf("%d.txt", j)), []byte("change"), 0644)
+		}
+	}
+
+	var output bytes.Buffer
+	console := Console
+	Console = &output
+	defer func() { Console = console }()
+
+	BeginCommandResult("workspace", []string{"status"})
+	assert.Nil(t, WorkspaceCommand(workspace, "status"))
+	results := CurrentResult.Data["results"].([]WorkspaceResult)
+	CurrentResult = nil
+
+	assert.Equal(t, len(labels), len(results))
+	assert.Equal(t, 0, len(ProjectOutputs))
+	for i, label := range labels {
+		assert.Equal(t, label, results[i].Label)
+		assert.True(t, results[i].Success)
+		assert.True(t, strings.HasPrefix(results[i].Detail, "dev-"+label+", "), results[i].Detail)
+		if i != 0 {
+			assert.Contains(t, results[i].Detail, fmt.Sprint(i))
+		}
+		assert.Contains(t, output.String(), T("workspace-finished", "status", label))
+	}
+}